@@ -4,7 +4,7 @@ import (
 	"os/exec"
 
 	"github.com/pluralsh/plural/pkg/application"
-	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/urfave/cli"
 	"sigs.k8s.io/application/api/v1beta1"
@@ -24,7 +24,8 @@ func handleWatch(c *cli.Context) error {
 	}
 
 	timeout := func() error { return nil }
-	return application.Waiter(kubeConf, repo, func(app *v1beta1.Application) (bool, error) {
+	conf := manifest.RepoConfig(repo)
+	return application.Waiter(kubeConf, &conf, repo, func(app *v1beta1.Application) (bool, error) {
 		tm.MoveCursor(1, 1)
 		application.Print(kube.Kube, app)
 		application.Flush()
@@ -39,12 +40,13 @@ func handleWait(c *cli.Context) error {
 		return err
 	}
 
-	return application.Wait(kubeConf, repo)
+	conf := manifest.RepoConfig(repo)
+	return application.Wait(kubeConf, &conf, repo)
 }
 
 func handleInfo(c *cli.Context) error {
 	repo := c.Args().Get(0)
-	conf := config.Read()
+	conf := manifest.RepoConfig(repo)
 	cmd := exec.Command("k9s", "-n", conf.Namespace(repo))
 	return cmd.Run()
 }
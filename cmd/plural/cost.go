@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/cost"
+	"github.com/pluralsh/plural/pkg/format"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/urfave/cli"
+)
+
+func priceTable(prov, url string) (*cost.PriceTable, error) {
+	if url != "" {
+		return cost.FetchCatalog(prov, url)
+	}
+	return cost.LoadCatalog(prov)
+}
+
+func handleCost(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos := c.Args()
+	if len(repos) == 0 {
+		repos, err = terraformRepos(root)
+		if err != nil {
+			return err
+		}
+	}
+
+	// repos can deploy to cluster targets on other providers, so each is priced against its own provider's catalog
+	catalogs := map[string]*cost.PriceTable{}
+	tables := make([]*cost.PriceTable, len(repos))
+	for i, repo := range repos {
+		prov, err := provider.ForRepo(repo)
+		if err != nil {
+			return err
+		}
+
+		table, ok := catalogs[prov.Name()]
+		if !ok {
+			if table, err = priceTable(prov.Name(), c.String("catalog")); err != nil {
+				return err
+			}
+			catalogs[prov.Name()] = table
+		}
+
+		if i > 0 && table.Currency != tables[0].Currency {
+			return fmt.Errorf("the catalogs for %s and %s are priced in different currencies", repos[0], repo)
+		}
+		tables[i] = table
+	}
+
+	currency := ""
+	if len(tables) > 0 {
+		currency = tables[0].Currency
+	}
+
+	formatter := format.New(format.FormatType(c.String("format")))
+	formatter.Header([]string{"Repo", "Resource", "Detail", fmt.Sprintf("Monthly (%s)", currency)})
+	total := 0.0
+	unpriced := make([]string, 0)
+	for i, repo := range repos {
+		utils.Highlight("Planning terraform for %s\n", repo)
+		resources, err := cost.Plan(pathing.SanitizeFilepath(filepath.Join(root, repo, "terraform")))
+		if err != nil {
+			return err
+		}
+
+		estimate := tables[i].Price(resources)
+		for _, item := range estimate.Items {
+			if err := formatter.Write([]string{repo, item.Address, item.Detail, money(item.Monthly)}); err != nil {
+				return err
+			}
+		}
+
+		if err := formatter.Write([]string{repo, "", "subtotal", money(estimate.Monthly)}); err != nil {
+			return err
+		}
+
+		total += estimate.Monthly
+		for _, address := range estimate.Unpriced {
+			unpriced = append(unpriced, fmt.Sprintf("%s: %s", repo, address))
+		}
+	}
+
+	if err := formatter.Write([]string{"", "", "total", money(total)}); err != nil {
+		return err
+	}
+
+	if err := formatter.Flush(); err != nil {
+		return err
+	}
+
+	if len(unpriced) > 0 {
+		utils.Warn("\nThe price catalog had no price for these resources, so they're excluded from the estimate:\n")
+		for _, address := range unpriced {
+			fmt.Printf("  %s\n", address)
+		}
+	}
+	return nil
+}
+
+// terraformRepos lists every installed repo in the workspace with a terraform directory
+func terraformRepos(root string) ([]string, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, entry.Name(), "terraform"))) {
+			repos = append(repos, entry.Name())
+		}
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
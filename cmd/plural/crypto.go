@@ -1,27 +1,28 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/mitchellh/go-homedir"
+	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
 
 	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/provider"
 	"github.com/pluralsh/plural/pkg/scm"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 )
 
-var prefix = []byte("CHARTMART-ENCRYPTED")
-
 const gitattributes = `/**/helm/**/values.yaml filter=plural-crypt diff=plural-crypt
 /**/helm/**/values.yaml* filter=plural-crypt diff=plural-crypt
+/**/helm/**/values.*.yaml filter=plural-crypt diff=plural-crypt
 /**/terraform/**/main.tf filter=plural-crypt diff=plural-crypt
 /**/terraform/**/main.tf* filter=plural-crypt diff=plural-crypt
 /**/manifest.yaml filter=plural-crypt diff=plural-crypt
@@ -34,6 +35,11 @@ workspace.yaml* filter=plural-crypt diff=plural-crypt
 .gitattributes !filter !diff
 `
 
+const auditHook = `#!/bin/sh
+# installed by plural crypto init, blocks commits containing plaintext secrets
+exec plural crypto audit --staged
+`
+
 const gitignore = `/**/.terraform
 /**/.terraform*
 /**/terraform.tfstate*
@@ -59,10 +65,38 @@ func cryptoCommands() []cli.Command {
 			Action: handleDecrypt,
 		},
 		{
-			Name:   "init",
-			Usage:  "initializes git filters for you",
+			Name:   "filter-process",
+			Usage:  "runs a git long-running filter process for encrypting and decrypting files",
+			Action: handleFilterProcess,
+			Hidden: true,
+		},
+		{
+			Name:  "init",
+			Usage: "initializes git filters for you",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "audit-hook",
+					Usage: "also install a git pre-commit hook that runs plural crypto audit",
+				},
+			},
 			Action: cryptoInit,
 		},
+		{
+			Name:  "audit",
+			Usage: "scans files not encrypted by the plural-crypt filter for plaintext secrets",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "staged",
+					Usage: "only scan files staged for commit",
+				},
+			},
+			Action: handleCryptoAudit,
+		},
+		{
+			Name:   "verify",
+			Usage:  "verifies every file matched by the plural-crypt filter is encrypted in the git index",
+			Action: handleCryptoVerify,
+		},
 		{
 			Name:   "unlock",
 			Usage:  "auto-decrypts all affected files in the repo",
@@ -90,6 +124,16 @@ func cryptoCommands() []cli.Command {
 			Usage:  "generate an ed5519 keypair for use in git ssh",
 			Action: affirmed(handleKeygen, "This command will autogenerate an ed5519 keypair, without passphrase. Sound good?"),
 		},
+		{
+			Name:   "backup",
+			Usage:  "backs up your aes key, encrypted with a passphrase, to your cloud provider's secret store",
+			Action: handleCryptoBackup,
+		},
+		{
+			Name:   "recover",
+			Usage:  "recovers your aes key from your cloud provider's secret store",
+			Action: handleCryptoRecover,
+		},
 		{
 			Name:   "export",
 			Usage:  "dumps the current aes key to stdout",
@@ -122,28 +166,12 @@ func cryptoCommands() []cli.Command {
 }
 
 func handleEncrypt(c *cli.Context) error {
-	data, err := ioutil.ReadAll(os.Stdin)
-	if bytes.HasPrefix(data, prefix) {
-		os.Stdout.Write(data)
-		return nil
-	}
-
-	if err != nil {
-		return err
-	}
-
 	prov, err := crypto.Build()
 	if err != nil {
 		return err
 	}
 
-	result, err := crypto.Encrypt(prov, data)
-	if err != nil {
-		return err
-	}
-	os.Stdout.Write(prefix)
-	os.Stdout.Write(result)
-	return nil
+	return crypto.EncryptTo(prov, os.Stdin, os.Stdout)
 }
 
 func handleDecrypt(c *cli.Context) error {
@@ -151,42 +179,44 @@ func handleDecrypt(c *cli.Context) error {
 	if c.Args().Present() {
 		p, _ := filepath.Abs(c.Args().First())
 		f, err := os.Open(p)
-		defer f.Close()
 		if err != nil {
 			return err
 		}
+		defer f.Close()
 		file = f
 	} else {
 		file = os.Stdin
 	}
 
-	data, err := ioutil.ReadAll(file)
-	if err != nil {
-		return err
-	}
-	if !bytes.HasPrefix(data, prefix) {
-		os.Stdout.Write(data)
-		return nil
-	}
-
 	prov, err := crypto.Build()
 	if err != nil {
 		return err
 	}
 
-	result, err := crypto.Decrypt(prov, data[len(prefix):])
+	return crypto.DecryptTo(prov, file, os.Stdout)
+}
+
+func handleFilterProcess(c *cli.Context) error {
+	prov, err := crypto.Build()
 	if err != nil {
 		return err
 	}
 
-	os.Stdout.Write(result)
-	return nil
+	return git.ServeFilter(os.Stdin, os.Stdout, map[string]git.FilterFunc{
+		"clean": func(pathname string, in io.Reader, out io.Writer) error {
+			return crypto.EncryptTo(prov, in, out)
+		},
+		"smudge": func(pathname string, in io.Reader, out io.Writer) error {
+			return crypto.DecryptTo(prov, in, out)
+		},
+	})
 }
 
 func cryptoInit(c *cli.Context) error {
 	encryptConfig := [][]string{
 		{"filter.plural-crypt.smudge", "plural crypto decrypt"},
 		{"filter.plural-crypt.clean", "plural crypto encrypt"},
+		{"filter.plural-crypt.process", "plural crypto filter-process"},
 		{"filter.plural-crypt.required", "true"},
 		{"diff.plural-crypt.textconv", "plural crypto decrypt"},
 	}
@@ -206,10 +236,75 @@ func cryptoInit(c *cli.Context) error {
 		return err
 	}
 
+	if c.Bool("audit-hook") {
+		if err := installAuditHook(); err != nil {
+			return err
+		}
+	}
+
 	_, err := crypto.Build()
 	return err
 }
 
+func installAuditHook() error {
+	repoRoot, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	hook, err := git.HookPath(repoRoot, "pre-commit")
+	if err != nil {
+		return err
+	}
+
+	if utils.Exists(hook) {
+		contents, err := utils.ReadFile(hook)
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(contents, "plural crypto audit") {
+			return nil
+		}
+
+		utils.Warn("A pre-commit hook already exists at %s, add `plural crypto audit --staged` to it manually\n", hook)
+		return nil
+	}
+
+	utils.Highlight("Installing secret scanning pre-commit hook\n\n")
+	if err := utils.WriteFile(hook, []byte(auditHook)); err != nil {
+		return err
+	}
+
+	return os.Chmod(hook, 0755)
+}
+
+func handleCryptoAudit(c *cli.Context) error {
+	repoRoot, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	findings, err := crypto.Audit(repoRoot, c.Bool("staged"))
+	if err != nil {
+		return err
+	}
+
+	if len(findings) == 0 {
+		utils.Success("No plaintext secrets found\n")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"File", "Line", "Rule", "Match"})
+	for _, finding := range findings {
+		table.Append([]string{finding.File, fmt.Sprintf("%d", finding.Line), finding.Rule, finding.Match})
+	}
+	table.Render()
+
+	return fmt.Errorf("found %d potential plaintext %s, encrypt the files with .gitattributes or mark the line with `plural-audit:ignore`", len(findings), utils.Pluralize("secret", "secrets", len(findings)))
+}
+
 func handleCryptoShare(c *cli.Context) error {
 	emails := c.StringSlice("email")
 	if err := crypto.SetupAge(emails); err != nil {
@@ -233,6 +328,43 @@ func handleSetupKeys(c *cli.Context) error {
 	return nil
 }
 
+func handleCryptoVerify(c *cli.Context) error {
+	repoRoot, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	prov, err := crypto.Build()
+	if err != nil {
+		return err
+	}
+
+	results, err := crypto.Verify(repoRoot, prov)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"File", "Status"})
+	for _, res := range results {
+		if res.Status == crypto.Encrypted {
+			continue
+		}
+
+		failed++
+		table.Append([]string{res.File, string(res.Status)})
+	}
+
+	if failed == 0 {
+		utils.Success("All %d encrypted files are valid for key %s\n", len(results), prov.ID())
+		return nil
+	}
+
+	table.Render()
+	return fmt.Errorf("%d of %d files were not properly encrypted, run `plural crypto init` and re-stage them", failed, len(results))
+}
+
 func handleUnlock(c *cli.Context) error {
 	repoRoot, err := git.Root()
 	if err != nil {
@@ -248,6 +380,85 @@ func handleUnlock(c *cli.Context) error {
 	return gitCommand("checkout", "HEAD", "--", repoRoot).Run()
 }
 
+func handleCryptoBackup(c *cli.Context) error {
+	prov, store, err := escrowStore()
+	if err != nil {
+		return err
+	}
+
+	passphrase := ""
+	prompt := &survey.Password{Message: "Enter a passphrase to encrypt your key backup with:"}
+	if err := survey.AskOne(prompt, &passphrase, survey.WithValidator(survey.MinLength(12))); err != nil {
+		return err
+	}
+
+	confirmation := ""
+	prompt = &survey.Password{Message: "Confirm your passphrase:"}
+	if err := survey.AskOne(prompt, &confirmation, survey.WithValidator(survey.Required)); err != nil {
+		return err
+	}
+
+	if passphrase != confirmation {
+		return fmt.Errorf("Passphrases did not match")
+	}
+
+	escrowed, err := crypto.EscrowKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	name := escrowName(prov)
+	if err := store.StoreSecret(name, escrowed); err != nil {
+		return err
+	}
+
+	utils.Success("Backed up your aes key to %s secret %s, keep your passphrase somewhere safe\n", prov.Name(), name)
+	return nil
+}
+
+func handleCryptoRecover(c *cli.Context) error {
+	prov, store, err := escrowStore()
+	if err != nil {
+		return err
+	}
+
+	escrowed, err := store.FetchSecret(escrowName(prov))
+	if err != nil {
+		return err
+	}
+
+	passphrase := ""
+	prompt := &survey.Password{Message: "Enter the passphrase for your key backup:"}
+	if err := survey.AskOne(prompt, &passphrase, survey.WithValidator(survey.Required)); err != nil {
+		return err
+	}
+
+	if err := crypto.RecoverKey(escrowed, passphrase); err != nil {
+		return err
+	}
+
+	utils.Success("Recovered your aes key, run `plural crypto unlock` to decrypt your repo\n")
+	return nil
+}
+
+func escrowStore() (provider.Provider, provider.SecretStore, error) {
+	if err := crypto.Escrowable(); err != nil {
+		return nil, nil, err
+	}
+
+	prov, err := getProvider()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := provider.GetSecretStore(prov)
+	return prov, store, err
+}
+
+func escrowName(prov provider.Provider) string {
+	return fmt.Sprintf("plural-%s-aes-key", strings.ToLower(prov.Cluster()))
+}
+
 func exportKey(c *cli.Context) error {
 	key, err := crypto.Materialize()
 	if err != nil {
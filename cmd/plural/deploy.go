@@ -246,7 +246,8 @@ func deploy(c *cli.Context) error {
 		if man, err := fetchManifest(repo); err == nil && man.Wait {
 			if kubeConf, err := utils.KubeConfig(); err == nil {
 				fmt.Println("")
-				if err := application.Wait(kubeConf, repo); err != nil {
+				conf := manifest.RepoConfig(repo)
+				if err := application.Wait(kubeConf, &conf, repo); err != nil {
 					return err
 				}
 				fmt.Println("")
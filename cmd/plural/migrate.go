@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+func handleMigrate(c *cli.Context) error {
+	to := c.String("to")
+	if to == "" {
+		return fmt.Errorf("you must specify the provider to migrate to with --to")
+	}
+
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	project, err := manifest.FetchProject()
+	if err != nil {
+		return err
+	}
+
+	from := project.Provider
+	if from == to {
+		return fmt.Errorf("this workspace already uses %s", to)
+	}
+
+	if !utils.Confirm(fmt.Sprintf("This will move your workspace from %s to %s and regenerate every repo, are you sure?", from, to)) {
+		return nil
+	}
+
+	repos, err := terraformRepos(root)
+	if err != nil {
+		return err
+	}
+
+	// state can hold secrets, so backups live under ~/.plural rather than in the repo
+	home, _ := os.UserHomeDir()
+	backupDir := pathing.SanitizeFilepath(filepath.Join(home, ".plural", "migrations", fmt.Sprintf("%s-%s", project.Cluster, from)))
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return err
+	}
+
+	checklist := make([]string, 0)
+	states := map[string][]byte{}
+	for _, repo := range repos {
+		utils.Highlight("Backing up terraform state for %s\n", repo)
+		state, err := wkspace.PullState(pathing.SanitizeFilepath(filepath.Join(root, repo, "terraform")))
+		if err != nil {
+			utils.Warn("%s\n", err)
+			checklist = append(checklist, fmt.Sprintf("Couldn't read the terraform state of %s, any of its %s resources will need to be cleaned up by hand", repo, from))
+			continue
+		}
+
+		if err := ioutil.WriteFile(pathing.SanitizeFilepath(filepath.Join(backupDir, repo+".tfstate")), state, 0600); err != nil {
+			return err
+		}
+		states[repo] = state
+	}
+
+	if network := project.Network; network != nil {
+		utils.Highlight("\nYou'll be asked for your network configuration again, use %s to keep your existing domain\n", network.Subdomain)
+	}
+
+	prov, err := provider.New(to)
+	if err != nil {
+		return err
+	}
+
+	if err := prov.Flush(); err != nil {
+		return err
+	}
+
+	// the new provider writes a fresh workspace.yaml, so carry over everything that isn't tied to the old provider
+	migrated, err := manifest.FetchProject()
+	if err != nil {
+		return err
+	}
+
+	migrated.Targets = project.Targets
+	migrated.TerraformVersion = project.TerraformVersion
+	migrated.Environment = project.Environment
+	migrated.EnforcePolicies = project.EnforcePolicies
+	migrated.PrivilegedRepos = project.PrivilegedRepos
+	// bucket names derived from the prefix should survive the move, even though the state bucket itself is new
+	if project.BucketPrefix != "" {
+		migrated.BucketPrefix = project.BucketPrefix
+	}
+	if migrated.Context == nil {
+		migrated.Context = map[string]interface{}{}
+	}
+	for key, val := range project.Context {
+		if _, ok := migrated.Context[key]; !ok {
+			migrated.Context[key] = val
+		}
+	}
+	if err := migrated.Write(manifest.ProjectManifestPath()); err != nil {
+		return err
+	}
+
+	client := api.NewClient()
+	installations, err := getSortedInstallations("", client)
+	if err != nil {
+		return err
+	}
+
+	for _, installation := range installations {
+		if err := doBuild(client, installation, true); err != nil {
+			return err
+		}
+	}
+
+	dropped := provider.TerraformProviders[from]
+	for _, repo := range repos {
+		state, ok := states[repo]
+		if !ok {
+			continue
+		}
+
+		utils.Highlight("Migrating terraform state for %s\n", repo)
+		migration, err := wkspace.MigrateState(repo, pathing.SanitizeFilepath(filepath.Join(root, repo, "terraform")), state, dropped)
+		if err != nil {
+			utils.Warn("%s\n", err)
+			checklist = append(checklist, fmt.Sprintf("Couldn't migrate the terraform state of %s, its backup can be pushed by hand with `terraform state push`", repo))
+			continue
+		}
+
+		for _, address := range migration.Dropped {
+			checklist = append(checklist, fmt.Sprintf("%s: %s is specific to %s and will be recreated on %s", repo, address, from, to))
+		}
+	}
+
+	checklist = append(checklist, migrationChecklist(project, from, to)...)
+	utils.Success("\nMigrated workspace to %s, before running `plural deploy` work through this checklist:\n\n", to)
+	for _, item := range checklist {
+		fmt.Printf("  [ ] %s\n", item)
+	}
+	fmt.Printf("\nBackups of your %s terraform state are in %s\n", from, backupDir)
+	return nil
+}
+
+func migrationChecklist(project *manifest.ProjectManifest, from, to string) []string {
+	checklist := make([]string, 0)
+	if network := project.Network; network != nil && !network.PluralDns {
+		checklist = append(checklist, fmt.Sprintf("Point the DNS records for %s at the ingress load balancer of your new %s cluster", network.Subdomain, to))
+	}
+
+	if ctx, err := manifest.ReadContext(manifest.ContextPath()); err == nil {
+		for _, bucket := range ctx.Buckets {
+			checklist = append(checklist, fmt.Sprintf("Copy the contents of bucket %s from %s to %s, bucket names are often globally unique so it may need renaming", bucket, from, to))
+		}
+	}
+
+	checklist = append(checklist,
+		fmt.Sprintf("Copy persistent volume data off the %s cluster, disks can't move between clouds", from),
+		fmt.Sprintf("Destroy the %s cluster and its resources once everything is running on %s, they're no longer tracked by this workspace", from, to),
+	)
+	return checklist
+}
@@ -2,25 +2,80 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"strings"
+	"time"
 
-	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/format"
 	"github.com/pluralsh/plural/pkg/provider"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/urfave/cli"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 )
 
+type drainOptions struct {
+	force   bool
+	timeout time.Duration
+}
+
 func opsCommands() []cli.Command {
 	return []cli.Command{
 		{
 			Name:      "terminate",
 			Usage:     "terminates a worker node in your cluster",
 			ArgsUsage: "NAME",
-			Action:    handleTerminateNode,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "terminate the node immediately, without draining it first",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "how long to wait for pods to be evicted and volumes to detach",
+					Value: utils.DefaultDrainTimeout,
+				},
+			},
+			Action: handleTerminateNode,
 		},
 		{
-			Name:   "cluster",
-			Usage:  "list the nodes in your cluster",
+			Name:  "rotate-nodes",
+			Usage: "drains and replaces the nodes in your cluster in batches, eg to pick up a new node image",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "pool",
+					Usage: "only rotate nodes in this node pool",
+				},
+				cli.IntFlag{
+					Name:  "batch",
+					Usage: "number of nodes to replace at a time",
+					Value: 1,
+				},
+				cli.BoolFlag{
+					Name:  "force",
+					Usage: "terminate nodes immediately, without draining them first",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "how long to wait for pods to be evicted and volumes to detach from each node",
+					Value: utils.DefaultDrainTimeout,
+				},
+				cli.DurationFlag{
+					Name:  "ready-timeout",
+					Usage: "how long to wait for replacement nodes and applications to become ready",
+					Value: 15 * time.Minute,
+				},
+			},
+			Action: handleRotateNodes,
+		},
+		{
+			Name:  "cluster",
+			Usage: "list the nodes in your cluster",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print the nodes out, eg csv or json, default is table",
+				},
+			},
 			Action: handleListNodes,
 		},
 	}
@@ -43,10 +98,35 @@ func handleTerminateNode(c *cli.Context) error {
 		return err
 	}
 
-	return provider.Decommision(node)
+	opts := drainOptions{force: c.Bool("force"), timeout: c.Duration("timeout")}
+	return decommisionNode(provider, kube, node, opts)
+}
+
+// decommisionNode cordons and drains a node, honoring PodDisruptionBudgets, and waits for its volumes
+// to detach before asking the provider to terminate it.  opts.force skips straight to termination.
+func decommisionNode(prov provider.Provider, kube *utils.Kube, node *v1.Node, opts drainOptions) error {
+	if !opts.force {
+		utils.Highlight("Cordoning node %s\n", node.Name)
+		if err := kube.Cordon(node); err != nil {
+			return err
+		}
+
+		utils.Highlight("Draining node %s\n", node.Name)
+		if err := kube.Drain(node, opts.timeout, false); err != nil {
+			return fmt.Errorf("%w, rerun with --force to terminate it anyway", err)
+		}
+
+		utils.Highlight("Waiting for volumes to detach from %s\n", node.Name)
+		if err := kube.WaitForVolumeDetach(node, opts.timeout); err != nil {
+			return fmt.Errorf("%w, rerun with --force to terminate it anyway", err)
+		}
+	}
+
+	utils.Highlight("Terminating node %s\n", node.Name)
+	return prov.Decommision(node)
 }
 
-func handleListNodes(cli *cli.Context) error {
+func handleListNodes(c *cli.Context) error {
 	kube, err := utils.Kubernetes()
 	if err != nil {
 		return err
@@ -57,22 +137,112 @@ func handleListNodes(cli *cli.Context) error {
 		return err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "CPU", "Memory", "Region", "Zone"})
-	for _, node := range nodes.Items {
-		status := node.Status
+	pods, err := kube.Pods("")
+	if err != nil {
+		return err
+	}
+
+	requests, podCounts := nodeRequests(pods)
+	// the metrics api is an optional addon, so just leave usage blank if it's missing
+	usage, _ := kube.NodeMetrics()
+
+	formatter := format.New(format.FormatType(c.String("format")))
+	formatter.Header([]string{
+		"Name", "Pool", "Instance Type", "Status", "CPU Allocatable", "CPU Requested", "CPU Used",
+		"Memory Allocatable", "Memory Requested", "Memory Used", "Pods", "Taints", "Region", "Zone", "Age",
+	})
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
 		labels := node.ObjectMeta.Labels
-		cpu, mem := status.Capacity["cpu"], status.Capacity["memory"]
-		table.Append([]string{
+		alloc, reqs, used := node.Status.Allocatable, requests[node.Name], usage[node.Name]
+		maxPods := alloc[v1.ResourcePods]
+		if err := formatter.Write([]string{
 			node.Name,
-			cpu.String(),
-			mem.String(),
+			nodePool(node),
+			instanceType(node),
+			nodeStatus(node),
+			quantity(alloc, v1.ResourceCPU),
+			quantity(reqs, v1.ResourceCPU),
+			quantity(used, v1.ResourceCPU),
+			quantity(alloc, v1.ResourceMemory),
+			quantity(reqs, v1.ResourceMemory),
+			quantity(used, v1.ResourceMemory),
+			fmt.Sprintf("%d/%s", podCounts[node.Name], maxPods.String()),
+			nodeTaints(node),
 			labels["topology.kubernetes.io/region"],
 			labels["topology.kubernetes.io/zone"],
-		})
+			duration.HumanDuration(time.Since(node.CreationTimestamp.Time)),
+		}); err != nil {
+			return err
+		}
+	}
+	return formatter.Flush()
+}
+
+// nodeRequests sums the resource requests and counts the pods scheduled to each node, ignoring finished pods
+func nodeRequests(pods *v1.PodList) (map[string]v1.ResourceList, map[string]int) {
+	requests, counts := map[string]v1.ResourceList{}, map[string]int{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		counts[pod.Spec.NodeName]++
+		if _, ok := requests[pod.Spec.NodeName]; !ok {
+			requests[pod.Spec.NodeName] = v1.ResourceList{}
+		}
+
+		total := requests[pod.Spec.NodeName]
+		for _, container := range pod.Spec.Containers {
+			for name, val := range container.Resources.Requests {
+				sum := total[name]
+				sum.Add(val)
+				total[name] = sum
+			}
+		}
+	}
+	return requests, counts
+}
+
+func quantity(resources v1.ResourceList, name v1.ResourceName) string {
+	if val, ok := resources[name]; ok {
+		return val.String()
+	}
+	return ""
+}
+
+func instanceType(node *v1.Node) string {
+	if instance, ok := node.Labels["node.kubernetes.io/instance-type"]; ok {
+		return instance
+	}
+	return node.Labels["beta.kubernetes.io/instance-type"]
+}
+
+// nodeStatus summarizes readiness along with any pressure conditions currently affecting the node
+func nodeStatus(node *v1.Node) string {
+	status := []string{"NotReady"}
+	if nodeReady(node) {
+		status[0] = "Ready"
+	}
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != v1.NodeReady && cond.Status == v1.ConditionTrue {
+			status = append(status, string(cond.Type))
+		}
+	}
+
+	if node.Spec.Unschedulable {
+		status = append(status, "SchedulingDisabled")
+	}
+	return strings.Join(status, ",")
+}
+
+func nodeTaints(node *v1.Node) string {
+	taints := make([]string, 0, len(node.Spec.Taints))
+	for _, taint := range node.Spec.Taints {
+		taints = append(taints, taint.ToString())
 	}
-	table.Render()
-	return nil
+	return strings.Join(taints, ", ")
 }
 
 func getProvider() (provider.Provider, error) {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/pluralsh/plural/pkg/provider"
 	"github.com/urfave/cli"
 )
 
@@ -190,6 +191,23 @@ func main() {
 			},
 			Action: tracked(handleInit, "cli.init"),
 		},
+		{
+			Name:      "cost",
+			Usage:     "estimates the monthly cost of the terraform in your workspace",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "catalog",
+					Usage: "url of a price catalog to fetch and cache in place of the one shipped with the cli",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print the estimate in, eg csv or json, default is table",
+				},
+			},
+			Action:   handleCost,
+			Category: "Workspace",
+		},
 		{
 			Name:   "preflights",
 			Usage:  "runs provider preflight checks",
@@ -242,9 +260,20 @@ func main() {
 			Category:    "API",
 		},
 		{
-			Name:     "test",
-			Usage:    "validate a values templace",
-			Action:   testTemplate,
+			Name:      "test",
+			Usage:     "renders the values templates under PATH against their test fixtures and compares them to the expected output",
+			ArgsUsage: "[PATH...]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "update",
+					Usage: "regenerate the expected output of every fixture instead of checking it",
+				},
+				cli.StringFlag{
+					Name:  "installation",
+					Usage: "render a template piped to stdin against the context of one of your installations instead",
+				},
+			},
+			Action:   handleTest,
 			Category: "Publishing",
 		},
 		{
@@ -297,6 +326,18 @@ func main() {
 			Subcommands: outputCommands(),
 			Category:    "Workspace",
 		},
+		{
+			Name:        "values",
+			Usage:       "Commands for inspecting the layered helm values of your installations",
+			Subcommands: valuesCommands(),
+			Category:    "Workspace",
+		},
+		{
+			Name:        "policy",
+			Usage:       "Commands for checking your installations against your organization's policies",
+			Subcommands: policyCommands(),
+			Category:    "Workspace",
+		},
 		{
 			Name:        "logs",
 			Usage:       "Commands for tailing logs for specific apps",
@@ -330,8 +371,9 @@ func main() {
 					Usage: "the values file",
 				},
 			},
-			Action:   handleHelmTemplate,
-			Category: "Publishing",
+			Subcommands: templateCommands(),
+			Action:      handleHelmTemplate,
+			Category:    "Publishing",
 		},
 		{
 			Name:    "upgrade",
@@ -374,6 +416,7 @@ func main() {
 	app.Commands = append(app.Commands, links...)
 
 	err := app.Run(os.Args)
+	provider.StopPlugins()
 	if err != nil {
 		log.Fatal(err)
 	}
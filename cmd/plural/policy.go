@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/format"
+	"github.com/pluralsh/plural/pkg/policy"
+	"github.com/pluralsh/plural/pkg/terraform"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/urfave/cli"
+)
+
+func policyCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:      "check",
+			Usage:     "checks the rendered helm manifests and terraform plans of repos against the policies in your workspace's policies directory",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "only",
+					Usage: "only check helm manifests or terraform plans, eg helm",
+				},
+				cli.BoolFlag{
+					Name:  "skip-deps",
+					Usage: "use the chart dependencies already downloaded instead of updating them",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print violations in, eg csv or json, default is table",
+				},
+			},
+			Action: handlePolicyCheck,
+		},
+	}
+}
+
+func handlePolicyCheck(c *cli.Context) error {
+	only := c.String("only")
+	if only != "" && only != "helm" && only != "terraform" {
+		return fmt.Errorf("--only must be either helm or terraform")
+	}
+
+	root, found := utils.ProjectRoot()
+	if !found {
+		return fmt.Errorf("Project not initialized, run `plural init` to set up a workspace")
+	}
+
+	policies, err := policy.LoadWorkspace(root)
+	if err != nil {
+		return err
+	}
+
+	if policies.Empty() {
+		utils.Warn("No policies found in %s, add *.rego or cel *.yaml policies there to check your repos against\n", policy.Dir)
+		return nil
+	}
+
+	repos := c.Args()
+	if len(repos) == 0 {
+		repos, err = deployableRepos(root)
+		if err != nil {
+			return err
+		}
+	}
+
+	violations := make([]*policy.Violation, 0)
+	for _, repo := range repos {
+		tfDir := pathing.SanitizeFilepath(filepath.Join(root, repo, "terraform"))
+		if only != "helm" && utils.Exists(tfDir) {
+			utils.Highlight("Planning terraform for %s\n", repo)
+			found, err := checkPlan(policies, repo, tfDir)
+			if err != nil {
+				return err
+			}
+			violations = append(violations, found...)
+		}
+
+		if only != "terraform" && utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))) {
+			utils.Highlight("Rendering helm chart for %s\n", repo)
+			manifests, err := renderChart(root, repo, c.Bool("skip-deps"), "")
+			if err != nil {
+				return err
+			}
+
+			found, err := policies.CheckManifests(repo, manifests)
+			if err != nil {
+				return err
+			}
+			violations = append(violations, found...)
+		}
+	}
+
+	if len(violations) == 0 {
+		utils.Success("No policy violations found\n")
+		return nil
+	}
+
+	formatter := format.New(format.FormatType(c.String("format")))
+	formatter.Header([]string{"Repo", "Policy", "Resource", "Violation"})
+	for _, v := range violations {
+		if err := formatter.Write([]string{v.Repo, v.Policy, v.Resource, v.Message}); err != nil {
+			return err
+		}
+	}
+
+	if err := formatter.Flush(); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("found %d policy %s", len(violations), utils.Pluralize("violation", "violations", len(violations)))
+}
+
+func checkPlan(policies *policy.Policies, repo, dir string) ([]*policy.Violation, error) {
+	tf, err := terraform.New(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tf.EnsureInit(); err != nil {
+		return nil, err
+	}
+
+	plan, err := tf.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	return policies.CheckPlan(repo, plan)
+}
+
+// deployableRepos lists every installed repo in the workspace with a helm chart or terraform to deploy
+func deployableRepos(root string) ([]string, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			continue
+		}
+
+		if utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, name, "terraform"))) ||
+			utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, name, "helm", name))) {
+			repos = append(repos, name)
+		}
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
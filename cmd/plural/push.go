@@ -155,7 +155,7 @@ func tmpValuesFile(path string, conf *config.Config) (f *os.File, err error) {
 	if err != nil {
 		return
 	}
-	tmpl, err := template.MakeTemplate(valuesTmpl)
+	tmpl, err := template.MakePrivilegedTemplate(valuesTmpl)
 	if err != nil {
 		return
 	}
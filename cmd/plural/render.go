@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/helm"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+func templateCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:      "render",
+			Usage:     "renders the kubernetes manifests of a repo's helm chart locally, without needing cluster access",
+			ArgsUsage: "REPO",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output-dir, o",
+					Usage: "write a file per chart template to this directory instead of stdout",
+				},
+				cli.BoolFlag{
+					Name:  "skip-deps",
+					Usage: "use the chart dependencies already downloaded instead of updating them",
+				},
+				cli.BoolFlag{
+					Name:  "validate",
+					Usage: "strictly validate the rendered resources against the schemas of their kinds",
+				},
+				cli.StringFlag{
+					Name:  "kube-version",
+					Usage: "the kubernetes version to render for, eg 1.22.0",
+				},
+			},
+			Action: handleTemplateRender,
+		},
+	}
+}
+
+func handleTemplateRender(c *cli.Context) error {
+	repo := c.Args().First()
+	if repo == "" {
+		return fmt.Errorf("usage: plural template render REPO")
+	}
+
+	root, found := utils.ProjectRoot()
+	if !found {
+		return fmt.Errorf("Project not initialized, run `plural init` to set up a workspace")
+	}
+
+	manifests, err := renderChart(root, repo, c.Bool("skip-deps"), c.String("kube-version"))
+	if err != nil {
+		return err
+	}
+
+	if dir := c.String("output-dir"); dir != "" {
+		for _, manifest := range manifests {
+			if err := utils.WriteFile(pathing.SanitizeFilepath(filepath.Join(dir, manifest.Source)), []byte(manifest.Content)); err != nil {
+				return err
+			}
+		}
+		utils.Success("Wrote %d manifests to %s\n", len(manifests), dir)
+	} else {
+		for _, manifest := range manifests {
+			fmt.Printf("---\n%s", manifest.Content)
+		}
+	}
+
+	if !c.Bool("validate") {
+		return nil
+	}
+
+	errs, skipped := helm.Conform(manifests)
+	if len(skipped) > 0 {
+		utils.Warn("Skipped validating %d resources of kinds without a known schema, eg %s\n", len(skipped), skipped[0])
+	}
+
+	if len(errs) == 0 {
+		fmt.Fprintln(os.Stderr, "All rendered resources are valid")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stderr)
+	table.SetHeader([]string{"Source", "Resource", "Error"})
+	table.SetAutoWrapText(false)
+	for _, err := range errs {
+		table.Append([]string{err.Source, err.Resource, err.Message})
+	}
+	table.Render()
+	return fmt.Errorf("found %d invalid %s", len(errs), utils.Pluralize("resource", "resources", len(errs)))
+}
+
+// renderChart templates a repo's helm chart with its current values, updating its dependencies first unless skipDeps is set
+func renderChart(root, repo string, skipDeps bool, kubeVersion string) ([]*helm.Manifest, error) {
+	conf := config.Read()
+	path := pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))
+	if !utils.Exists(path) {
+		return nil, fmt.Errorf("%s has no helm chart, you might need to run `plural build --only %s`", repo, repo)
+	}
+
+	if !skipDeps {
+		os.Setenv("HELM_REPO_ACCESS_TOKEN", conf.Token)
+		// progress goes to stderr so the manifests can be piped elsewhere
+		if err := helm.UpdateDependencies(path, os.Stderr); err != nil {
+			return nil, err
+		}
+	}
+
+	vals, err := wkspace.RenderValues(root, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return helm.Render(conf.Namespace(repo), repo, path, vals, &helm.RenderOptions{KubeVersion: kubeVersion})
+}
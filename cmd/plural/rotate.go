@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/application"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/urfave/cli"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const rotatePollInterval = 10 * time.Second
+
+// labels the managed kubernetes offerings use to record which node pool a node belongs to
+var nodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"node-pool",
+}
+
+func nodePool(node *v1.Node) string {
+	for _, label := range nodePoolLabels {
+		if pool, ok := node.Labels[label]; ok {
+			return pool
+		}
+	}
+	return ""
+}
+
+func nodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func handleRotateNodes(c *cli.Context) error {
+	pool, batch := c.String("pool"), c.Int("batch")
+	if batch < 1 {
+		return fmt.Errorf("--batch must be at least 1")
+	}
+
+	prov, err := getProvider()
+	if err != nil {
+		return err
+	}
+
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := poolNodes(kube, pool, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		return fmt.Errorf("No nodes found in pool %s", pool)
+	}
+
+	opts := drainOptions{force: c.Bool("force"), timeout: c.Duration("timeout")}
+	replaced := map[string]bool{}
+	for i := 0; i < len(nodes); i += batch {
+		end := i + batch
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+
+		for _, node := range nodes[i:end] {
+			if err := decommisionNode(prov, kube, node, opts); err != nil {
+				return err
+			}
+			replaced[node.Name] = true
+		}
+
+		utils.Highlight("Waiting for %d replacement nodes to become ready\n", end-i)
+		if err := waitForReplacements(kube, pool, replaced, len(nodes), c.Duration("ready-timeout")); err != nil {
+			return err
+		}
+
+		utils.Highlight("Waiting for applications to become healthy\n")
+		if err := waitForApplications(kube, c.Duration("ready-timeout")); err != nil {
+			return err
+		}
+
+		utils.Success("Rotated %d of %d nodes\n", end, len(nodes))
+	}
+
+	return nil
+}
+
+// poolNodes lists the nodes in pool (or every node if pool is empty), oldest first and skipping any in exclude
+func poolNodes(kube *utils.Kube, pool string, exclude map[string]bool) ([]*v1.Node, error) {
+	nodes, err := kube.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*v1.Node, 0)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if exclude[node.Name] || (pool != "" && nodePool(node) != pool) {
+			continue
+		}
+		result = append(result, node)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreationTimestamp.Before(&result[j].CreationTimestamp)
+	})
+	return result, nil
+}
+
+// waitForReplacements waits until the pool is back to its original size, not counting nodes we've already replaced
+func waitForReplacements(kube *utils.Kube, pool string, replaced map[string]bool, size int, timeout time.Duration) error {
+	err := wait.PollImmediate(rotatePollInterval, timeout, func() (bool, error) {
+		nodes, err := poolNodes(kube, pool, replaced)
+		if err != nil {
+			return false, err
+		}
+
+		ready := 0
+		for _, node := range nodes {
+			if nodeReady(node) && !node.Spec.Unschedulable {
+				ready++
+			}
+		}
+		return ready >= size, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("Replacement nodes failed to become ready after %s, check your cloud provider's node group for errors", timeout)
+	}
+	return err
+}
+
+func waitForApplications(kube *utils.Kube, timeout time.Duration) error {
+	ctx := context.Background()
+	err := wait.PollImmediate(rotatePollInterval, timeout, func() (bool, error) {
+		apps, err := kube.Application.Applications("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		healthy := true
+		for i := range apps.Items {
+			healthy = application.Ready(&apps.Items[i]) && healthy
+		}
+		application.Flush()
+		return healthy, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("Applications failed to become healthy after %s, run `plural watch APP` to see what's wrong", timeout)
+	}
+	return err
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/pluralsh/plural-operator/api/platform/v1alpha1"
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/template"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/urfave/cli"
 	"io/ioutil"
 	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
@@ -14,11 +17,107 @@ import (
 	"os"
 )
 
-func testTemplate(c *cli.Context) error {
+func handleTest(c *cli.Context) error {
+	if repo := c.String("installation"); repo != "" {
+		return testTemplate(repo)
+	}
+
+	paths := c.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	fixtures := make([]string, 0)
+	for _, path := range paths {
+		if !utils.Exists(path) {
+			// plural test REPO < template was how templates were tested against an installation before fixtures
+			if len(paths) == 1 && stdinPiped() {
+				return testTemplate(path)
+			}
+			return fmt.Errorf("%s doesn't exist, to render a template on stdin against an installation use `plural test --installation %s`", path, path)
+		}
+
+		found, err := template.FindFixtures(path)
+		if err != nil {
+			return err
+		}
+		fixtures = append(fixtures, found...)
+	}
+
+	if len(fixtures) == 0 {
+		utils.Warn("No fixtures found, add them to a %s directory alongside your values.yaml.tpl or terraform.tfvars\n", template.FixtureDir)
+		return nil
+	}
+
+	failed := 0
+	for _, path := range fixtures {
+		if err := runFixture(path, c.Bool("update")); err != nil {
+			utils.Error("\u2717 %s: %s\n", path, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d template tests failed", failed, len(fixtures))
+	}
+
+	if c.Bool("update") {
+		utils.Success("Updated the expected output of %d %s\n", len(fixtures), utils.Pluralize("fixture", "fixtures", len(fixtures)))
+		return nil
+	}
+	utils.Success("All %d template tests passed\n", len(fixtures))
+	return nil
+}
+
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// runFixture renders a fixture and checks it against its golden file, printing a diff if they differ
+func runFixture(path string, update bool) error {
+	fixture, err := template.ReadFixture(path)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := fixture.Render()
+	if err != nil {
+		return err
+	}
+
+	golden := fixture.GoldenPath()
+	if update {
+		return ioutil.WriteFile(golden, []byte(rendered), 0644)
+	}
+
+	expected, err := utils.ReadFile(golden)
+	if err != nil {
+		return fmt.Errorf("no expected output at %s, run `plural test --update` to create it", golden)
+	}
+
+	if expected == rendered {
+		utils.Success("\u2713 %s\n", path)
+		return nil
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(expected),
+		B:        difflib.SplitLines(rendered),
+		FromFile: "expected " + golden,
+		ToFile:   "rendered " + fixture.TemplatePath(),
+		Context:  3,
+	}
+	if err := difflib.WriteUnifiedDiff(os.Stdout, diff); err != nil {
+		return err
+	}
+	return fmt.Errorf("rendered output doesn't match %s", golden)
+}
+
+func testTemplate(repoName string) error {
 	conf := config.Read()
 	client := api.NewClient()
 	installations, _ := client.GetInstallations()
-	repoName := c.Args().Get(0)
 	testTemplate, err := ioutil.ReadAll(os.Stdin)
 	if err != nil {
 		return err
@@ -30,7 +129,7 @@ func testTemplate(c *cli.Context) error {
 		}
 
 		ctx := installation.Context
-		tmpl, err := template.MakeTemplate(string(testTemplate))
+		tmpl, err := template.MakePrivilegedTemplate(string(testTemplate))
 		if err != nil {
 			return err
 		}
@@ -1,20 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
-	"github.com/coreos/go-semver/semver"
+	"github.com/pluralsh/plural/pkg/bump"
 	"github.com/pluralsh/plural/pkg/utils"
-	"github.com/pluralsh/plural/pkg/utils/pathing"
-	"github.com/thoas/go-funk"
 	"github.com/urfave/cli"
-	"gopkg.in/yaml.v2"
 )
 
 func utilsCommands() []cli.Command {
@@ -22,7 +15,7 @@ func utilsCommands() []cli.Command {
 		{
 			Name:      "image-bump",
 			ArgsUsage: "CHART",
-			Usage:     "Bumps a chart's image tag",
+			Usage:     "Bumps the image tags of a chart, or of every chart in a directory, along with their versions",
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "path",
@@ -32,6 +25,23 @@ func utilsCommands() []cli.Command {
 					Name:  "tag",
 					Usage: "the image tag to set to",
 				},
+				cli.StringSliceFlag{
+					Name:  "image, i",
+					Usage: "an image tag to set like [CHART:]PATH=TAG, can be passed multiple times",
+				},
+				cli.StringFlag{
+					Name:  "from-file",
+					Usage: "a json file of [CHART:]PATH keys to the image tags to set",
+				},
+				cli.StringFlag{
+					Name:  "bump",
+					Usage: "which part of the chart version to bump, one of patch, minor or major",
+					Value: string(bump.Patch),
+				},
+				cli.StringFlag{
+					Name:  "message, m",
+					Usage: "a note to add to the changelog entry of every bumped chart",
+				},
 			},
 			Action: handleImageBump,
 		},
@@ -39,90 +49,70 @@ func utilsCommands() []cli.Command {
 }
 
 func handleImageBump(c *cli.Context) error {
-	chartPath := c.Args().Get(0)
-	path, tag := c.String("path"), c.String("tag")
-
-	chartPath, _ = filepath.Abs(chartPath)
-
-	chart := make(map[string]interface{})
-	vals := make(map[string]interface{})
-
-	if err := readHelmYaml(pathing.SanitizeFilepath(filepath.Join(chartPath, "Chart.yaml")), &chart); err != nil {
+	chartPath, err := filepath.Abs(c.Args().Get(0))
+	if err != nil {
 		return err
 	}
 
-	if err := readHelmYaml(pathing.SanitizeFilepath(filepath.Join(chartPath, "values.yaml")), &vals); err != nil {
+	policy, err := bump.ParsePolicy(c.String("bump"))
+	if err != nil {
 		return err
 	}
 
-	currentTag := funk.Get(vals, path)
-	if currentTag == tag {
-		utils.Highlight("No change in version tag\n")
-		return nil
-	}
-
-	currentVersion := (chart["version"]).(string)
-	sv, err := semver.NewVersion(currentVersion)
+	images, err := bumpImages(c)
 	if err != nil {
 		return err
 	}
 
-	if err := replaceVals(pathing.SanitizeFilepath(filepath.Join(chartPath, "values.yaml")), tag); err != nil {
+	results, err := bump.Run(chartPath, images, &bump.Options{Policy: policy, Message: c.String("message")})
+	if err != nil {
 		return err
 	}
 
-	sv.BumpPatch()
-	chart["version"] = sv.String()
+	if len(results) == 0 {
+		utils.Highlight("No change in version tag\n")
+		return nil
+	}
 
-	return writeHelmYaml(pathing.SanitizeFilepath(filepath.Join(chartPath, "Chart.yaml")), chart)
+	for _, result := range results {
+		utils.Success("%s %s -> %s\n", result.Chart, result.From, result.To)
+		for _, change := range result.Changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+	return nil
 }
 
-const replPattern = `(?s).*## PLRL-REPLACE\[(.*)\].*`
-
-func replaceVals(path string, val string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
+func bumpImages(c *cli.Context) ([]*bump.Image, error) {
+	images := make([]*bump.Image, 0)
+	if path := c.String("path"); path != "" {
+		images = append(images, &bump.Image{Path: path, Tag: c.String("tag")})
 	}
-	defer file.Close()
 
-	result := make([]string, 0)
-	scanner := bufio.NewScanner(file)
-	// optionally, resize scanner's capacity for lines over 64K, see next example
-	re := regexp.MustCompile(replPattern)
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := re.FindStringSubmatch(line)
-		if len(matches) > 0 {
-			line = fmt.Sprintf(matches[1]+" ## PLRL-REPLACE[%s]", val, matches[1])
+	for _, spec := range c.StringSlice("image") {
+		image, err := bump.ParseImage(spec)
+		if err != nil {
+			return nil, err
 		}
-
-		result = append(result, line)
+		images = append(images, image)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+	if file := c.String("from-file"); file != "" {
+		fromFile, err := bump.ReadImages(file)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, fromFile...)
 	}
 
-	content := strings.Join(result, "\n")
-
-	return ioutil.WriteFile(path, []byte(content), 0644)
-}
-
-func readHelmYaml(path string, result *map[string]interface{}) error {
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return err
+	if len(images) == 0 {
+		return nil, fmt.Errorf("pass the images to bump with --image, --from-file or --path and --tag")
 	}
 
-	return yaml.Unmarshal(content, result)
-}
-
-func writeHelmYaml(path string, vals map[string]interface{}) error {
-	io, err := yaml.Marshal(&vals)
-	if err != nil {
-		return err
+	for _, image := range images {
+		if strings.TrimSpace(image.Tag) == "" {
+			return nil, fmt.Errorf("no tag given for %s", image.Path)
+		}
 	}
-
-	return ioutil.WriteFile(path, io, 0644)
+	return images, nil
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/overlay"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+func valuesCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:      "explain",
+			Usage:     "shows which values layer sets a key in a repo's helm values",
+			ArgsUsage: "REPO KEY",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "env",
+					Usage: "the environment overlay to explain, defaults to the one in workspace.yaml",
+				},
+			},
+			Action: handleExplainValues,
+		},
+	}
+}
+
+func handleExplainValues(c *cli.Context) error {
+	repo, key := c.Args().Get(0), c.Args().Get(1)
+	if repo == "" || key == "" {
+		return fmt.Errorf("usage: plural values explain REPO KEY, eg plural values explain airbyte postgres.enabled")
+	}
+
+	root, found := utils.ProjectRoot()
+	if !found {
+		return fmt.Errorf("Project not initialized, run `plural init` to set up a workspace")
+	}
+
+	env := c.String("env")
+	if env == "" {
+		project, err := manifest.FetchProject()
+		if err != nil {
+			return err
+		}
+		env = project.Environment
+	}
+
+	dir := pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))
+	if !utils.Exists(pathing.SanitizeFilepath(filepath.Join(dir, overlay.GeneratedFile))) {
+		return fmt.Errorf("%s has no generated values, run `plural build --only %s` first", repo, repo)
+	}
+
+	layers, err := overlay.Load(dir, env)
+	if err != nil {
+		return err
+	}
+
+	settings := overlay.Explain(layers, key)
+	if len(settings) == 0 {
+		utils.Warn("%s isn't set by any values layer of %s\n", key, repo)
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Layer", "File", "Value"})
+	table.SetAutoWrapText(false)
+	for _, setting := range settings {
+		file, _ := filepath.Rel(root, setting.Layer.Path)
+		table.Append([]string{setting.Layer.Name, file, displayValue(setting.Value)})
+	}
+	table.Render()
+
+	winner := settings[len(settings)-1]
+	if _, ok := winner.Value.(map[string]interface{}); ok {
+		names := make([]string, 0)
+		for _, setting := range settings {
+			if _, ok := setting.Value.(map[string]interface{}); ok {
+				names = append(names, setting.Layer.Name)
+			}
+		}
+		utils.Success("\n%s is merged from the %s layers, later layers win\n", key, strings.Join(names, ", "))
+		return nil
+	}
+
+	utils.Success("\n%s is set by the %s layer\n", key, winner.Layer.Name)
+	return nil
+}
+
+func displayValue(val interface{}) string {
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		out, err := yaml.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return strings.TrimSpace(string(out))
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/olekukonko/tablewriter"
+	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/terraform"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/wkspace"
 	"github.com/pluralsh/plural/pkg/helm"
@@ -16,9 +19,32 @@ import (
 func workspaceCommands() []cli.Command {
 	return []cli.Command{
 		{
-			Name:   "kube-init",
-			Usage:  "generates kubernetes credentials for this subworkspace",
-			Action: kubeInit,
+			Name:      "kube-init",
+			Usage:     "generates kubernetes credentials for this subworkspace",
+			ArgsUsage: "[REPO]",
+			Action:    kubeInit,
+		},
+		{
+			Name:   "targets",
+			Usage:  "lists the clusters this workspace can deploy to",
+			Action: listTargets,
+		},
+		{
+			Name:      "target",
+			Usage:     "sets the cluster target a repo deploys to, you'll need to rebuild it afterwards",
+			ArgsUsage: "REPO TARGET",
+			Action:    setTarget,
+		},
+		{
+			Name:  "migrate",
+			Usage: "moves this workspace to a different cloud provider",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "the provider to migrate to, eg aws",
+				},
+			},
+			Action: handleMigrate,
 		},
 		{
 			Name:      "helm",
@@ -54,6 +80,24 @@ func workspaceCommands() []cli.Command {
 			ArgsUsage: "NAME",
 			Action:    diffTerraform,
 		},
+		{
+			Name:      "terraform-init",
+			Usage:     "initializes the terraform module in DIR, defaulting to the current directory",
+			ArgsUsage: "[DIR]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "upgrade",
+					Usage: "upgrade providers and modules to the newest versions allowed",
+				},
+			},
+			Action: terraformInit,
+		},
+		{
+			Name:      "terraform-apply",
+			Usage:     "plans and applies the terraform module in DIR, defaulting to the current directory",
+			ArgsUsage: "[DIR]",
+			Action:    terraformApply,
+		},
 		{
 			Name:      "crds",
 			Usage:     "installs the crds for this repo",
@@ -69,7 +113,21 @@ func kubeInit(c *cli.Context) error {
 		return fmt.Errorf("Project not initialized, run `plural init` to set up a workspace")
 	}
 
-	prov, err := provider.GetProvider()
+	// kube-init runs from within a repo's directory during deploys, so use its manifest to find the right target
+	repo := c.Args().First()
+	if repo == "" {
+		if man, err := manifest.Read("manifest.yaml"); err == nil {
+			repo = man.Name
+		}
+	}
+
+	var prov provider.Provider
+	var err error
+	if repo != "" {
+		prov, err = provider.ForRepo(repo)
+	} else {
+		prov, err = provider.GetProvider()
+	}
 	if err != nil {
 		return err
 	}
@@ -77,26 +135,64 @@ func kubeInit(c *cli.Context) error {
 	return prov.KubeConfig()
 }
 
-func bounceHelm(c *cli.Context) error {
-	name := c.Args().Get(0)
-	minimal, err := wkspace.Minimal(name)
+func listTargets(c *cli.Context) error {
+	project, err := manifest.FetchProject()
 	if err != nil {
 		return err
 	}
 
-	args := []string{}
-	if c.IsSet("wait") {
-		args = append(args, "--wait")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Target", "Provider", "Cluster", "Region", "Bucket"})
+	table.Append([]string{manifest.PrimaryTarget, project.Provider, project.Cluster, project.Region, project.Bucket})
+	for _, target := range project.Targets {
+		table.Append([]string{target.Name, target.Provider, target.Cluster, target.Region, target.Bucket})
 	}
-	if c.IsSet("skip") {
-		for _, skipChart := range c.StringSlice("skip") {
-			skipString := fmt.Sprintf("%s.enabled=false", skipChart)
-			skip := []string{"--set", skipString}
-			args = append(args, skip...)
-		}
+	table.Render()
+	return nil
+}
+
+func setTarget(c *cli.Context) error {
+	repo, target := c.Args().Get(0), c.Args().Get(1)
+	if repo == "" || target == "" {
+		return fmt.Errorf("usage: plural workspace target REPO TARGET")
+	}
+
+	project, err := manifest.FetchProject()
+	if err != nil {
+		return err
 	}
 
-	return minimal.BounceHelm(args...)
+	if _, err := project.Target(target); err != nil {
+		return err
+	}
+
+	path, err := manifest.ManifestPath(repo)
+	if err != nil {
+		return err
+	}
+
+	man, err := manifest.Read(path)
+	if err != nil {
+		return fmt.Errorf("Could not find an installation of %s in this workspace", repo)
+	}
+
+	man.Target = target
+	if err := man.Write(path); err != nil {
+		return err
+	}
+
+	utils.Success("%s will deploy to %s, run `plural build --only %s` to regenerate it\n", repo, target, repo)
+	return nil
+}
+
+func bounceHelm(c *cli.Context) error {
+	name := c.Args().Get(0)
+	minimal, err := wkspace.Minimal(name)
+	if err != nil {
+		return err
+	}
+
+	return minimal.BounceHelm(c.Bool("wait"), c.StringSlice("skip")...)
 }
 
 func diffHelm(c *cli.Context) error {
@@ -119,6 +215,41 @@ func diffTerraform(c *cli.Context) error {
 	return minimal.DiffTerraform()
 }
 
+func terraformDir(c *cli.Context) string {
+	if dir := c.Args().First(); dir != "" {
+		return dir
+	}
+	return "."
+}
+
+func terraformInit(c *cli.Context) error {
+	tf, err := terraform.New(terraformDir(c))
+	if err != nil {
+		return err
+	}
+
+	tf.SetOutput(os.Stdout)
+	return tf.Init(c.Bool("upgrade"))
+}
+
+func terraformApply(c *cli.Context) error {
+	tf, err := terraform.New(terraformDir(c))
+	if err != nil {
+		return err
+	}
+
+	tf.SetOutput(os.Stdout)
+	changes, err := tf.Apply()
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		fmt.Printf("%s %s\n", change.Address, change.Action)
+	}
+	return nil
+}
+
 func createCrds(c *cli.Context) error {
 	if empty, err := utils.IsEmpty("crds"); err != nil || empty {
 		return err
@@ -154,5 +285,5 @@ func updateDeps(c *cli.Context) error {
 		path = "."
 	}
 
-	return helm.UpdateDependencies(path)
+	return helm.UpdateDependencies(path, os.Stdout)
 }
\ No newline at end of file
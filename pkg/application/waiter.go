@@ -15,8 +15,8 @@ const (
 	waitTime = 5 * 60 * time.Second
 )
 
-func Waiter(kubeConf *rest.Config, repo string, appFunc func(app *v1beta1.Application) (bool, error), timeout func() error) error {
-	conf := config.Read()
+// Waiter watches a repo's application in the namespace conf, the config of the repo's cluster target, puts it in
+func Waiter(kubeConf *rest.Config, conf *config.Config, repo string, appFunc func(app *v1beta1.Application) (bool, error), timeout func() error) error {
 	ctx := context.Background()
 	apps, err := NewForConfig(kubeConf)
 	if err != nil {
@@ -60,12 +60,12 @@ func Waiter(kubeConf *rest.Config, repo string, appFunc func(app *v1beta1.Applic
 	}
 }
 
-func Wait(kubeConf *rest.Config, repo string) error {
+func Wait(kubeConf *rest.Config, conf *config.Config, repo string) error {
 	timeout := func() error {
 		return fmt.Errorf("Failed to become ready after 5 minutes, try running `plural watch %s` to get an idea where to debug", repo)
 	}
 
-	return Waiter(kubeConf, repo, func(app *v1beta1.Application) (bool, error) {
+	return Waiter(kubeConf, conf, repo, func(app *v1beta1.Application) (bool, error) {
 		tm.MoveCursor(1, 1)
 		ready := Ready(app)
 		Flush()
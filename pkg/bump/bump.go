@@ -0,0 +1,336 @@
+package bump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// Policy is which part of a chart's version is bumped when its images change
+type Policy string
+
+const (
+	Patch Policy = "patch"
+	Minor Policy = "minor"
+	Major Policy = "major"
+)
+
+func ParsePolicy(policy string) (Policy, error) {
+	switch p := Policy(policy); p {
+	case "":
+		return Patch, nil
+	case Patch, Minor, Major:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid bump policy %s, must be one of patch, minor or major", policy)
+	}
+}
+
+func (p Policy) apply(version *semver.Version) *semver.Version {
+	next := *version
+	switch p {
+	case Major:
+		next.BumpMajor()
+	case Minor:
+		next.BumpMinor()
+	default:
+		next.BumpPatch()
+	}
+	return &next
+}
+
+// Image is a new tag for the image at Path in the values of Chart, or of every chart if Chart is empty
+type Image struct {
+	Chart string
+	Path  string
+	Tag   string
+}
+
+// ParseImage parses an image spec like [CHART:]PATH=TAG
+func ParseImage(spec string) (*Image, error) {
+	path, tag, ok := strings.Cut(spec, "=")
+	if !ok || path == "" || tag == "" {
+		return nil, fmt.Errorf("invalid image %s, must look like [CHART:]PATH=TAG", spec)
+	}
+
+	image := &Image{Path: path, Tag: tag}
+	if chart, p, ok := strings.Cut(path, ":"); ok {
+		image.Chart, image.Path = chart, p
+	}
+	return image, nil
+}
+
+// ReadImages reads images from a json object of [CHART:]PATH keys to tags
+func ReadImages(path string) ([]*Image, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags map[string]string
+	if err := json.Unmarshal(contents, &tags); err != nil {
+		return nil, fmt.Errorf("%s must be a json object of [CHART:]PATH keys to tags: %w", path, err)
+	}
+
+	images := make([]*Image, 0, len(tags))
+	for path, tag := range tags {
+		image, err := ParseImage(fmt.Sprintf("%s=%s", path, tag))
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Chart+":"+images[i].Path < images[j].Chart+":"+images[j].Path })
+	return images, nil
+}
+
+// Change is a value a bump changed, either an image tag or the version of a dependency
+type Change struct {
+	Path       string
+	From       string
+	To         string
+	Dependency bool
+}
+
+func (c *Change) String() string {
+	if c.Dependency {
+		return fmt.Sprintf("updated dependency %s from %s to %s", c.Path, c.From, c.To)
+	}
+	return fmt.Sprintf("bumped %s from %s to %s", c.Path, c.From, c.To)
+}
+
+// Result is a chart that was bumped
+type Result struct {
+	Chart   string
+	From    string
+	To      string
+	Changes []*Change
+}
+
+type Options struct {
+	Policy Policy
+	// added to the changelog entry of every chart bumped
+	Message string
+}
+
+type pending struct {
+	chart   *chart
+	values  string
+	version *semver.Version
+	changes []*Change
+}
+
+// Run sets the tags of images in the chart at path, or every chart beneath path if it isn't one.  Charts whose
+// images changed have their version bumped according to the policy and a changelog entry added, then every chart
+// pinning one of them as a dependency, among its siblings or beneath path, is updated to the new version, along with
+// its lock file, and has its patch version bumped in turn.
+func Run(path string, images []*Image, opts *Options) ([]*Result, error) {
+	targets, pool, err := discover(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCharts(images, targets); err != nil {
+		return nil, err
+	}
+
+	bumped := map[string]*pending{}
+	found := map[*Image]bool{}
+	for _, c := range targets {
+		tags := tagsFor(c.Name, images)
+		if len(tags) == 0 {
+			continue
+		}
+
+		valuesFile := pathing.SanitizeFilepath(filepath.Join(c.Dir, "values.yaml"))
+		if !utils.Exists(valuesFile) {
+			continue
+		}
+
+		contents, err := utils.ReadFile(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		values, changes, matched := replaceMarkers(contents, tags)
+		for _, image := range images {
+			if matched[image.Path] && (image.Chart == "" || image.Chart == c.Name) {
+				found[image] = true
+			}
+		}
+
+		if len(changes) > 0 {
+			bumped[c.Name] = &pending{chart: c, values: values, version: opts.Policy.apply(c.Version), changes: changes}
+		}
+	}
+
+	for _, image := range images {
+		if !found[image] {
+			return nil, fmt.Errorf("no chart has a PLRL-REPLACE marker for %s in its values.yaml", image.Path)
+		}
+	}
+
+	propagate(bumped, pool)
+
+	names := make([]string, 0, len(bumped))
+	for name := range bumped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]*Result, 0, len(names))
+	for _, name := range names {
+		p := bumped[name]
+		if err := p.write(opts.Message); err != nil {
+			return nil, err
+		}
+		results = append(results, &Result{Chart: name, From: p.chart.Version.String(), To: p.version.String(), Changes: p.changes})
+	}
+	return results, nil
+}
+
+// propagate updates charts pinning a bumped chart as a dependency, bumping their patch version if nothing else has
+func propagate(bumped map[string]*pending, pool []*chart) {
+	queue := make([]string, 0, len(bumped))
+	for name := range bumped {
+		queue = append(queue, name)
+	}
+	sort.Strings(queue)
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		version := bumped[name].version.String()
+
+		for _, c := range pool {
+			for _, dep := range c.dependencies() {
+				// ranges are left alone, they were chosen to float
+				if _, err := semver.NewVersion(dep.Version); dep.Name != name || err != nil || dep.Version == version {
+					continue
+				}
+
+				p, ok := bumped[c.Name]
+				if !ok {
+					p = &pending{chart: c, version: Patch.apply(c.Version)}
+					bumped[c.Name] = p
+					queue = append(queue, c.Name)
+				}
+
+				p.changes = append(p.changes, &Change{Path: dep.Name, From: dep.Version, To: version, Dependency: true})
+				dep.file.pin(dep, version)
+			}
+		}
+	}
+}
+
+func (p *pending) write(message string) error {
+	if p.values != "" {
+		valuesFile := pathing.SanitizeFilepath(filepath.Join(p.chart.Dir, "values.yaml"))
+		if err := ioutil.WriteFile(valuesFile, []byte(p.values), 0644); err != nil {
+			return err
+		}
+	}
+
+	p.chart.setVersion(p.version)
+	if err := p.chart.flush(); err != nil {
+		return err
+	}
+	return writeChangelog(p.chart.Dir, p.version.String(), message, p.changes)
+}
+
+// tagsFor is the tags to set in the values of the named chart, by path
+func tagsFor(name string, images []*Image) map[string]string {
+	tags := map[string]string{}
+	for _, image := range images {
+		if image.Chart == "" {
+			if _, ok := tags[image.Path]; !ok {
+				tags[image.Path] = image.Tag
+			}
+		}
+	}
+
+	// images for a specific chart take precedence
+	for _, image := range images {
+		if image.Chart == name {
+			tags[image.Path] = image.Tag
+		}
+	}
+	return tags
+}
+
+func checkCharts(images []*Image, targets []*chart) error {
+	names := map[string]bool{}
+	for _, c := range targets {
+		names[c.Name] = true
+	}
+
+	for _, image := range images {
+		if image.Chart != "" && !names[image.Chart] {
+			return fmt.Errorf("no chart named %s to set %s in", image.Chart, image.Path)
+		}
+	}
+	return nil
+}
+
+// discover finds the charts to bump, and the ones that could depend on them.  For a single chart that's its siblings.
+func discover(path string) (targets []*chart, pool []*chart, err error) {
+	if utils.Exists(pathing.SanitizeFilepath(filepath.Join(path, "Chart.yaml"))) {
+		c, err := readChart(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		siblings, err := findCharts(filepath.Dir(path))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pool = []*chart{c}
+		for _, sibling := range siblings {
+			if sibling.Name != c.Name {
+				pool = append(pool, sibling)
+			}
+		}
+		return []*chart{c}, pool, nil
+	}
+
+	targets, err = findCharts(path)
+	if err == nil && len(targets) == 0 {
+		err = fmt.Errorf("no charts found in %s", path)
+	}
+	return targets, targets, err
+}
+
+// findCharts reads every chart beneath root, skipping the vendored subcharts in their charts directories
+func findCharts(root string) ([]*chart, error) {
+	charts := make([]*chart, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+
+		if name := info.Name(); path != root && (name == ".git" || name == "charts") {
+			return filepath.SkipDir
+		}
+
+		if !utils.Exists(pathing.SanitizeFilepath(filepath.Join(path, "Chart.yaml"))) {
+			return nil
+		}
+
+		c, err := readChart(path)
+		if err != nil {
+			return err
+		}
+		charts = append(charts, c)
+		return filepath.SkipDir
+	})
+	return charts, err
+}
@@ -0,0 +1,49 @@
+package bump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+const (
+	changelogFile  = "CHANGELOG.md"
+	changelogTitle = "# Changelog"
+)
+
+// writeChangelog adds an entry for version to the top of the chart's CHANGELOG.md, creating it if needed
+func writeChangelog(dir, version, message string, changes []*Change) error {
+	var entry strings.Builder
+	entry.WriteString(fmt.Sprintf("## %s - %s\n\n", version, time.Now().Format("2006-01-02")))
+	if message != "" {
+		entry.WriteString(message + "\n\n")
+	}
+	for _, change := range changes {
+		entry.WriteString(fmt.Sprintf("- %s\n", change))
+	}
+
+	path := pathing.SanitizeFilepath(filepath.Join(dir, changelogFile))
+	existing, err := utils.ReadFile(path)
+	if err != nil {
+		existing = changelogTitle + "\n"
+	}
+
+	// keep the title of the changelog above the new entry
+	title, rest := "", existing
+	if strings.HasPrefix(existing, "# ") {
+		title, rest, _ = strings.Cut(existing, "\n")
+		title += "\n\n"
+		rest = strings.TrimLeft(rest, "\n")
+	}
+
+	contents := title + entry.String()
+	if rest != "" {
+		contents += "\n" + rest
+	}
+	return ioutil.WriteFile(path, []byte(contents), 0644)
+}
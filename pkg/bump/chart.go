@@ -0,0 +1,181 @@
+package bump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v3"
+)
+
+// chartFile is a yaml file of a chart, edited in place so its comments and formatting survive a bump
+type chartFile struct {
+	path  string
+	lines []string
+	root  *yaml.Node
+	dirty bool
+	// previous versions of the dependencies a bump re-pinned, their lock file has to follow
+	pinned map[string]string
+}
+
+type dependency struct {
+	Name    string
+	Version string
+	file    *chartFile
+	node    *yaml.Node
+}
+
+type chart struct {
+	Name    string
+	Dir     string
+	Version *semver.Version
+
+	chartYaml    *chartFile
+	requirements *chartFile
+}
+
+func readChart(dir string) (*chart, error) {
+	chartYaml, err := readChartFile(pathing.SanitizeFilepath(filepath.Join(dir, "Chart.yaml")))
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := chartYaml.scalar("name")
+	version, ok := chartYaml.scalar("version")
+	if name == nil || !ok {
+		return nil, fmt.Errorf("%s has no name or version", chartYaml.path)
+	}
+
+	sv, err := semver.NewVersion(version.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version in %s: %w", chartYaml.path, err)
+	}
+
+	c := &chart{Name: name.Value, Dir: dir, Version: sv, chartYaml: chartYaml}
+	// helm 2 charts list their dependencies in requirements.yaml instead
+	if path := pathing.SanitizeFilepath(filepath.Join(dir, "requirements.yaml")); utils.Exists(path) {
+		if c.requirements, err = readChartFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *chart) setVersion(version *semver.Version) {
+	node, _ := c.chartYaml.scalar("version")
+	c.chartYaml.set(node, version.String())
+}
+
+func (c *chart) dependencies() []*dependency {
+	deps := c.chartYaml.dependencies()
+	if c.requirements != nil {
+		deps = append(deps, c.requirements.dependencies()...)
+	}
+	return deps
+}
+
+func (c *chart) flush() error {
+	for _, file := range []*chartFile{c.chartYaml, c.requirements} {
+		if file == nil || !file.dirty {
+			continue
+		}
+
+		if err := ioutil.WriteFile(file.path, []byte(strings.Join(file.lines, "\n")), 0644); err != nil {
+			return err
+		}
+
+		if len(file.pinned) > 0 {
+			if err := syncLock(file.path, file.pinned); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readChartFile(path string) (*chartFile, error) {
+	contents, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(contents), &doc); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s isn't a yaml map", path)
+	}
+	return &chartFile{path: path, lines: strings.Split(contents, "\n"), root: doc.Content[0]}, nil
+}
+
+func (f *chartFile) scalar(name string) (*yaml.Node, bool) {
+	node := mapValue(f.root, name)
+	return node, node != nil && node.Kind == yaml.ScalarNode
+}
+
+func (f *chartFile) dependencies() []*dependency {
+	seq := mapValue(f.root, "dependencies")
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	deps := make([]*dependency, 0, len(seq.Content))
+	for _, item := range seq.Content {
+		name, version := mapValue(item, "name"), mapValue(item, "version")
+		if name == nil || version == nil {
+			continue
+		}
+		deps = append(deps, &dependency{Name: name.Value, Version: version.Value, file: f, node: version})
+	}
+	return deps
+}
+
+// set rewrites the scalar node in the file's text, keeping its quoting
+func (f *chartFile) set(node *yaml.Node, value string) {
+	quote := ""
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		quote = `"`
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		quote = `'`
+	}
+
+	line := f.lines[node.Line-1]
+	prefix, rest := line[:node.Column-1], line[node.Column-1:]
+	old := quote + node.Value + quote
+	if strings.HasPrefix(rest, old) {
+		f.lines[node.Line-1] = prefix + quote + value + quote + rest[len(old):]
+		node.Value = value
+		f.dirty = true
+	}
+}
+
+// pin sets the version of one of the file's dependencies
+func (f *chartFile) pin(dep *dependency, version string) {
+	if f.pinned == nil {
+		f.pinned = map[string]string{}
+	}
+	if _, ok := f.pinned[dep.Name]; !ok {
+		f.pinned[dep.Name] = dep.node.Value
+	}
+	f.set(dep.node, version)
+}
+
+func mapValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
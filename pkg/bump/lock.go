@@ -0,0 +1,112 @@
+package bump
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/provenance"
+	"sigs.k8s.io/yaml"
+)
+
+// syncLock updates the lock file beside a Chart.yaml or requirements.yaml whose dependency pins changed, so
+// helm dependency build doesn't reject it as out of sync.  If the lock's digest can't be reproduced, eg because
+// it was generated against repo aliases, it's removed instead and the next helm dependency update regenerates it.
+func syncLock(depsFile string, pinned map[string]string) error {
+	lockFile := strings.TrimSuffix(depsFile, filepath.Ext(depsFile)) + ".lock"
+	if !utils.Exists(lockFile) {
+		return nil
+	}
+
+	var lock helmchart.Lock
+	contents, err := ioutil.ReadFile(lockFile)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(contents, &lock); err != nil {
+		return os.Remove(lockFile)
+	}
+
+	reqs, err := lockedRequirements(depsFile, &lock, pinned)
+	if err != nil {
+		return os.Remove(lockFile)
+	}
+
+	digest, err := hashReq(reqs, lock.Dependencies)
+	if err != nil {
+		return err
+	}
+
+	lock.Digest = digest
+	lock.Generated = time.Now()
+	res, err := yaml.Marshal(&lock)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(lockFile, res, 0644)
+}
+
+var errLockMismatch = errors.New("lock file doesn't match its dependencies")
+
+// lockedRequirements reads the dependencies of depsFile, first checking lock was generated from them as they were
+// before pinned changed, then moves the lock's entries for pinned to their new versions
+func lockedRequirements(depsFile string, lock *helmchart.Lock, pinned map[string]string) ([]*helmchart.Dependency, error) {
+	contents, err := ioutil.ReadFile(depsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Dependencies []*helmchart.Dependency `json:"dependencies"`
+	}
+	if err := yaml.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+
+	if len(file.Dependencies) != len(lock.Dependencies) {
+		return nil, errLockMismatch
+	}
+
+	// helm locks dependencies in the order they're listed
+	previous := make([]*helmchart.Dependency, len(file.Dependencies))
+	for i, req := range file.Dependencies {
+		if lock.Dependencies[i].Name != req.Name {
+			return nil, errLockMismatch
+		}
+
+		prev := *req
+		if version, ok := pinned[req.Name]; ok {
+			prev.Version = version
+		}
+		previous[i] = &prev
+	}
+
+	if digest, err := hashReq(previous, lock.Dependencies); err != nil || digest != lock.Digest {
+		return nil, errLockMismatch
+	}
+
+	for i, req := range file.Dependencies {
+		if _, ok := pinned[req.Name]; ok {
+			lock.Dependencies[i].Version = req.Version
+		}
+	}
+	return file.Dependencies, nil
+}
+
+// hashReq is helm's digest of a chart's dependencies and their lock, see helm's internal/resolver
+func hashReq(req, lock []*helmchart.Dependency) (string, error) {
+	data, err := json.Marshal([2][]*helmchart.Dependency{req, lock})
+	if err != nil {
+		return "", err
+	}
+
+	s, err := provenance.Digest(bytes.NewBuffer(data))
+	return "sha256:" + s, err
+}
@@ -0,0 +1,112 @@
+package bump
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	markerPattern = regexp.MustCompile(`## PLRL-REPLACE\[(.*)\]`)
+	keyPattern    = regexp.MustCompile(`^(\s*)([^\s#:-][^:#]*?)\s*:(\s|$)`)
+	itemPattern   = regexp.MustCompile(`^(\s*)-(\s+|$)`)
+)
+
+// key is a map key or, for list items, the item's index
+type key struct {
+	indent int
+	name   string
+	item   bool
+}
+
+// replaceMarkers sets the value of every `## PLRL-REPLACE[<format>]` marker in a values file whose dotted path has a
+// tag in tags.  List items are addressed by index, so the image of a map in the first item of sidecars is
+// sidecars.0.image.  It returns the rewritten contents, the values it changed and the paths of tags a marker was found for.
+func replaceMarkers(contents string, tags map[string]string) (string, []*Change, map[string]bool) {
+	changes := make([]*Change, 0)
+	found := map[string]bool{}
+	stack := make([]key, 0)
+	lines := strings.Split(contents, "\n")
+	for i, line := range lines {
+		// a list item opens a new entry in the list, and its line can go on to the first key of a map inside it
+		offset := 0
+		for {
+			item := itemPattern.FindString(line[offset:])
+			if item == "" {
+				break
+			}
+
+			stack = pushItem(stack, offset+len(strings.TrimRight(item, " \t"))-1)
+			offset += len(item)
+		}
+
+		value := line[offset:]
+		match := keyPattern.FindStringSubmatch(value)
+		if match == nil && offset == 0 {
+			continue
+		}
+
+		if match != nil {
+			// keys indented at or past this one belong to a map that's already closed
+			indent := offset + len(match[1])
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, key{indent: indent, name: strings.Trim(match[2], `"'`)})
+			value = value[len(match[0]):]
+		}
+
+		marker := markerPattern.FindStringSubmatch(line)
+		if marker == nil {
+			continue
+		}
+
+		path := joinKeys(stack)
+		tag, ok := tags[path]
+		if !ok {
+			continue
+		}
+
+		found[path] = true
+		if current := currentValue(value); current != tag {
+			lines[i] = fmt.Sprintf(marker[1]+" ## PLRL-REPLACE[%s]", tag, marker[1])
+			changes = append(changes, &Change{Path: path, From: current, To: tag})
+		}
+	}
+
+	return strings.Join(lines, "\n"), changes, found
+}
+
+// pushItem adds the list item whose dash is at column indent to stack, numbering it after the previous item of
+// the same list.  A list can sit at the same indent as the key holding it, so only items are closed at that indent.
+func pushItem(stack []key, indent int) []key {
+	index := 0
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.indent < indent || (top.indent == indent && !top.item) {
+			break
+		}
+
+		if top.indent == indent {
+			index, _ = strconv.Atoi(top.name)
+			index++
+		}
+		stack = stack[:len(stack)-1]
+	}
+	return append(stack, key{indent: indent, name: strconv.Itoa(index), item: true})
+}
+
+// currentValue is the value of a line after its key or list dash, without the marker
+func currentValue(value string) string {
+	value = markerPattern.ReplaceAllString(value, "")
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+func joinKeys(stack []key) string {
+	names := make([]string, len(stack))
+	for i, k := range stack {
+		names[i] = k.name
+	}
+	return strings.Join(names, ".")
+}
@@ -122,6 +122,11 @@ func (c *Config) Namespace(ns string) string {
 	return ns
 }
 
+// Redacted is the part of the config it's safe to hand templates that aren't trusted with the token
+func (c *Config) Redacted() *Config {
+	return &Config{Email: c.Email, NamespacePrefix: c.NamespacePrefix}
+}
+
 func (c *Config) Url() string {
 	return c.BaseUrl() + "/gql"
 }
@@ -0,0 +1,112 @@
+package cost
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+const HoursPerMonth = 730
+
+// catalogs holds the offline price tables shipped with the cli, a table cached in ~/.plural/prices takes precedence
+//
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+var catalogFiles = map[string]string{
+	"aws":     "aws.json",
+	"google":  "gcp.json",
+	"azure":   "azure.json",
+	"equinix": "equinix.json",
+}
+
+// ResourcePrice describes how to price a single terraform resource type from its planned attributes.  Attribute
+// paths are dot separated, with numeric segments indexing into lists, eg scaling_config.0.desired_size
+type ResourcePrice struct {
+	// flat monthly price of the resource, eg for a managed control plane or nat gateway
+	Monthly float64 `json:"monthly"`
+	// attribute holding an instance type to price from the table's hourly instance prices
+	InstanceType string `json:"instanceType"`
+	// attributes holding the number of instances, the first one set wins, defaults to one
+	Count []string `json:"count"`
+	// attribute holding a quantity billed at UnitPrice a month, eg a disk size in GB
+	Units     string  `json:"units"`
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+type PriceTable struct {
+	Provider  string                    `json:"provider"`
+	Currency  string                    `json:"currency"`
+	Instances map[string]float64        `json:"instances"`
+	Resources map[string]*ResourcePrice `json:"resources"`
+}
+
+func cachePath(provider string) string {
+	folder, _ := os.UserHomeDir()
+	return pathing.SanitizeFilepath(filepath.Join(folder, ".plural", "prices", provider+".json"))
+}
+
+// LoadCatalog returns the price table for provider, preferring one previously fetched with FetchCatalog
+func LoadCatalog(provider string) (*PriceTable, error) {
+	if contents, err := ioutil.ReadFile(cachePath(provider)); err == nil {
+		return parseCatalog(contents)
+	}
+
+	file, ok := catalogFiles[provider]
+	if !ok {
+		return nil, fmt.Errorf("No price catalog available for provider %s, you can supply one with --catalog", provider)
+	}
+
+	contents, err := catalogs.ReadFile("catalogs/" + file)
+	if err != nil {
+		return nil, err
+	}
+	return parseCatalog(contents)
+}
+
+// FetchCatalog downloads a price table for provider and caches it for future estimates
+func FetchCatalog(provider, url string) (*PriceTable, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to fetch price catalog from %s: %s", url, resp.Status)
+	}
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := parseCatalog(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	if table.Provider != provider {
+		return nil, fmt.Errorf("The catalog at %s is for provider %s, not %s", url, table.Provider, provider)
+	}
+
+	path := cachePath(provider)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return table, ioutil.WriteFile(path, contents, 0644)
+}
+
+func parseCatalog(contents []byte) (*PriceTable, error) {
+	var table PriceTable
+	if err := json.Unmarshal(contents, &table); err != nil {
+		return nil, fmt.Errorf("Invalid price catalog: %w", err)
+	}
+	return &table, nil
+}
@@ -0,0 +1,147 @@
+package cost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pluralsh/plural/pkg/terraform"
+)
+
+// Resource is a managed resource as it will exist once a terraform plan is applied
+type Resource struct {
+	Address string
+	Type    string
+	Values  map[string]interface{}
+}
+
+type LineItem struct {
+	Address string
+	Type    string
+	Detail  string
+	Monthly float64
+}
+
+type Estimate struct {
+	Items    []*LineItem
+	Unpriced []string
+	Monthly  float64
+}
+
+// Plan runs terraform plan in dir and returns every resource that would exist after applying it
+func Plan(dir string) ([]*Resource, error) {
+	tf, err := terraform.New(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tf.EnsureInit(); err != nil {
+		return nil, err
+	}
+
+	plan, err := tf.Plan()
+	if err != nil {
+		return nil, fmt.Errorf("terraform plan failed in %s: %w", dir, err)
+	}
+
+	resources := make([]*Resource, 0)
+	for _, change := range plan.ResourceChanges {
+		if change.Mode != tfjson.ManagedResourceMode || change.Change == nil {
+			continue
+		}
+
+		after, ok := change.Change.After.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resources = append(resources, &Resource{Address: change.Address, Type: change.Type, Values: after})
+	}
+	return resources, nil
+}
+
+// Price estimates the monthly cost of resources using table, resources of types the table
+// doesn't know about, or with instance types it has no price for, are listed as unpriced
+func (table *PriceTable) Price(resources []*Resource) *Estimate {
+	estimate := &Estimate{Items: make([]*LineItem, 0), Unpriced: make([]string, 0)}
+	for _, resource := range resources {
+		price, ok := table.Resources[resource.Type]
+		if !ok {
+			continue
+		}
+
+		item, ok := table.priceResource(price, resource)
+		if !ok {
+			estimate.Unpriced = append(estimate.Unpriced, resource.Address)
+			continue
+		}
+
+		estimate.Items = append(estimate.Items, item)
+		estimate.Monthly += item.Monthly
+	}
+	return estimate
+}
+
+func (table *PriceTable) priceResource(price *ResourcePrice, resource *Resource) (*LineItem, bool) {
+	item := &LineItem{Address: resource.Address, Type: resource.Type, Monthly: price.Monthly}
+	details := make([]string, 0)
+
+	if price.InstanceType != "" {
+		instance, _ := lookup(resource.Values, price.InstanceType).(string)
+		hourly, ok := table.Instances[instance]
+		if !ok {
+			return nil, false
+		}
+
+		count := 1.0
+		for _, path := range price.Count {
+			if val, ok := number(lookup(resource.Values, path)); ok {
+				count = val
+				break
+			}
+		}
+
+		item.Monthly += hourly * HoursPerMonth * count
+		details = append(details, fmt.Sprintf("%gx %s", count, instance))
+	}
+
+	if price.Units != "" {
+		if units, ok := number(lookup(resource.Values, price.Units)); ok {
+			item.Monthly += units * price.UnitPrice
+			details = append(details, fmt.Sprintf("%g units", units))
+		}
+	}
+
+	item.Detail = strings.Join(details, ", ")
+	return item, true
+}
+
+func lookup(values interface{}, path string) interface{} {
+	for _, segment := range strings.Split(path, ".") {
+		switch val := values.(type) {
+		case map[string]interface{}:
+			values = val[segment]
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil
+			}
+			values = val[idx]
+		default:
+			return nil
+		}
+	}
+	return values
+}
+
+func number(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,202 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+const (
+	cryptFilter       = "plural-crypt"
+	auditIgnoreMarker = "plural-audit:ignore"
+	minSecretLength   = 20
+	base64Entropy     = 4.5
+	hexEntropy        = 3.5
+	// sha1 commit ids and checksums are 40 characters, and indistinguishable from random keys
+	minHexLength = 41
+)
+
+// lock files are full of checksums, which are indistinguishable from random keys
+var auditSkipFiles = map[string]bool{
+	"Chart.lock":          true,
+	"requirements.lock":   true,
+	"go.sum":              true,
+	".terraform.lock.hcl": true,
+	"package-lock.json":   true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"Gemfile.lock":        true,
+	"Cargo.lock":          true,
+	"poetry.lock":         true,
+	"composer.lock":       true,
+	"mix.lock":            true,
+}
+
+type secretRule struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+var secretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"age-identity", regexp.MustCompile(`AGE-SECRET-KEY-1[0-9A-Z]{58}`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?(access_?)?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{"gcp-service-account", regexp.MustCompile(`"private_key_id"\s*:\s*"[0-9a-f]{40}"`)},
+	{"github-token", regexp.MustCompile(`\b(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abpors]-[0-9A-Za-z\-]{10,}`)},
+	{"stripe-key", regexp.MustCompile(`\b(sk|rk)_live_[0-9A-Za-z]{24,}`)},
+	{"password-assignment", regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key)["']?\s*[:=]\s*["'][^"'\s$\{]{8,}["']`)},
+}
+
+var (
+	base64Token = regexp.MustCompile(`[A-Za-z0-9+/=]{20,}`)
+	hexToken    = regexp.MustCompile(`[a-fA-F0-9]{20,}`)
+	digestToken = regexp.MustCompile(`(sha1|sha256|sha512|h1):[A-Za-z0-9+/=]+`)
+)
+
+type Finding struct {
+	File  string
+	Line  int
+	Rule  string
+	Match string
+}
+
+// Audit scans all files in the repo not covered by the plural-crypt git filter for plaintext
+// secrets.  If staged is set, only the contents of the git index are scanned.
+func Audit(root string, staged bool) ([]*Finding, error) {
+	var files []string
+	var err error
+	if staged {
+		files, err = git.Staged(root)
+	} else {
+		files, err = git.Files(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := git.Attribute(root, "filter", files)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]*Finding, 0)
+	for _, file := range files {
+		if filters[file] == cryptFilter || auditSkipFiles[filepath.Base(file)] {
+			continue
+		}
+
+		// tracked files deleted from the working tree have nothing to scan, and submodules are scanned in their own repo
+		info, err := os.Stat(pathing.SanitizeFilepath(filepath.Join(root, file)))
+		switch {
+		case err == nil && info.IsDir():
+			continue
+		case os.IsNotExist(err) && !staged:
+			continue
+		}
+
+		var content []byte
+		if staged {
+			content, err = git.IndexBlob(root, file)
+		} else {
+			content, err = ioutil.ReadFile(pathing.SanitizeFilepath(filepath.Join(root, file)))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		findings = append(findings, AuditContent(file, content)...)
+	}
+
+	return findings, nil
+}
+
+// AuditContent scans a single file's content line by line for known secret formats and high entropy strings
+func AuditContent(file string, content []byte) []*Finding {
+	findings := make([]*Finding, 0)
+	if isBinary(content) {
+		return findings
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := scanner.Text()
+		if strings.Contains(line, auditIgnoreMarker) {
+			continue
+		}
+
+		if finding := auditLine(line); finding != nil {
+			finding.File = file
+			finding.Line = lineno
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+func auditLine(line string) *Finding {
+	for _, rule := range secretRules {
+		if match := rule.regex.FindString(line); match != "" {
+			return &Finding{Rule: rule.name, Match: redact(match)}
+		}
+	}
+
+	stripped := digestToken.ReplaceAllString(line, "")
+	for _, tok := range hexToken.FindAllString(stripped, -1) {
+		if len(tok) >= minHexLength && shannonEntropy(tok) > hexEntropy {
+			return &Finding{Rule: "high-entropy-hex", Match: redact(tok)}
+		}
+	}
+
+	for _, tok := range base64Token.FindAllString(stripped, -1) {
+		if len(tok) >= minSecretLength && shannonEntropy(tok) > base64Entropy {
+			return &Finding{Rule: "high-entropy-base64", Match: redact(tok)}
+		}
+	}
+
+	return nil
+}
+
+func shannonEntropy(str string) float64 {
+	counts := map[rune]float64{}
+	for _, r := range str {
+		counts[r]++
+	}
+
+	entropy := 0.0
+	length := float64(len(str))
+	for _, count := range counts {
+		freq := count / length
+		entropy -= freq * math.Log2(freq)
+	}
+	return entropy
+}
+
+func redact(match string) string {
+	if len(match) <= 8 {
+		return "****"
+	}
+	return match[:6] + "****"
+}
+
+func isBinary(content []byte) bool {
+	sample := content
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return bytes.IndexByte(sample, 0) >= 0
+}
@@ -1,10 +1,14 @@
 package crypto
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
+	"filippo.io/age"
+	"filippo.io/age/armor"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 )
@@ -45,3 +49,84 @@ func backupPath(ind int) string {
 
 	return pathing.SanitizeFilepath(filepath.Join(folder, ".plural", "keybackups", fmt.Sprintf("key_backup%s", infix)))
 }
+
+// EscrowKey wraps the current aes key with a passphrase-derived age recipient, so it can be
+// safely stored in a remote secret manager
+func EscrowKey(passphrase string) ([]byte, error) {
+	key, err := Materialize()
+	if err != nil {
+		return nil, err
+	}
+
+	keydata, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	writer, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(keydata); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RecoverKey unwraps a key created by EscrowKey and installs it, backing up any existing local key
+func RecoverKey(escrowed []byte, passphrase string) error {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return err
+	}
+
+	reader, err := age.Decrypt(armor.NewReader(bytes.NewReader(escrowed)), identity)
+	if err != nil {
+		return fmt.Errorf("could not decrypt key backup, is your passphrase correct? %w", err)
+	}
+
+	keydata, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	key, err := DeserializeKey(keydata)
+	if err != nil {
+		return err
+	}
+
+	return Setup(key.Key)
+}
+
+// Escrowable errors unless the workspace is encrypted with the aes key, age workspaces are decrypted with their
+// members' identities, which an escrowed aes key can't stand in for
+func Escrowable() error {
+	if !utils.Exists(configPath()) {
+		return nil
+	}
+
+	conf, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	if conf.Type != KEY {
+		return fmt.Errorf("only workspaces encrypted with an aes key can be backed up, this one uses %s, keep a copy of your identity at %s instead", conf.Type, getAgePath())
+	}
+	return nil
+}
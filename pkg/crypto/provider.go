@@ -12,6 +12,9 @@ type Provider interface {
 	Marshall() ([]byte, error)
 }
 
+// Prefix marks a file as having been encrypted by the plural-crypt git filter
+var Prefix = []byte("CHARTMART-ENCRYPTED")
+
 const (
 	KEY IdentityType = "key"
 	AGE IdentityType = "age"
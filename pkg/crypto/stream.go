@@ -0,0 +1,234 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// files larger than this are encrypted in chunks rather than a single gcm seal
+	streamThreshold = 1024 * 1024
+	streamChunkSize = 64 * 1024
+	streamKdfInfo   = "plural-crypt stream v1"
+)
+
+// streamHeader follows Prefix in chunked files.  Legacy single-seal files go straight on to their gcm nonce, the
+// first 12 bytes of the plaintext's sha256, then the sealed content.
+var streamHeader = []byte("\x00PLURAL-STREAM-V1\x00")
+
+// EncryptTo encrypts in to out, passing through content that is already encrypted.  Small files use the
+// single-seal format from Encrypt, anything above streamThreshold is split into independently sealed chunks.
+func EncryptTo(prov Provider, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReaderSize(in, streamThreshold+1)
+	head, err := reader.Peek(streamThreshold + 1)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if bytes.HasPrefix(head, Prefix) {
+		_, err := io.Copy(out, reader)
+		return err
+	}
+
+	key, err := prov.SymmetricKey()
+	if err != nil {
+		return err
+	}
+
+	if len(head) <= streamThreshold {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		result, err := encrypt(key, data)
+		if err != nil {
+			return err
+		}
+
+		return writeAll(out, Prefix, result)
+	}
+
+	if err := writeAll(out, Prefix, streamHeader); err != nil {
+		return err
+	}
+	return encryptStream(key, reader, out)
+}
+
+// DecryptTo decrypts in to out, detecting both the single-seal and chunked formats and
+// passing through anything that was never encrypted
+func DecryptTo(prov Provider, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	head, err := reader.Peek(len(Prefix) + len(streamHeader))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if !bytes.HasPrefix(head, Prefix) {
+		_, err := io.Copy(out, reader)
+		return err
+	}
+
+	key, err := prov.SymmetricKey()
+	if err != nil {
+		return err
+	}
+
+	if _, err := reader.Discard(len(Prefix)); err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(head[len(Prefix):], streamHeader) {
+		if _, err := reader.Discard(len(streamHeader)); err != nil {
+			return err
+		}
+		return decryptStream(key, reader, out)
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	result, err := decrypt(key, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(result)
+	return err
+}
+
+// encryptStream seals each chunk with a nonce derived from an hmac of its position and content, so
+// encryption stays deterministic (which git needs to not see every file as modified) without ever
+// reusing a nonce for different plaintext.  The chunk counter and a final-chunk flag are bound in as
+// additional data, so chunks can't be reordered, dropped or truncated without failing decryption.
+func encryptStream(key []byte, in *bufio.Reader, out io.Writer) error {
+	aead, macKey, err := streamCipher(key)
+	if err != nil {
+		return err
+	}
+
+	chunk := make([]byte, streamChunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(in, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		last, err := atEOF(in)
+		if err != nil {
+			return err
+		}
+
+		ad := streamAD(counter, last)
+		nonce := streamNonce(macKey, ad, chunk[:n], aead.NonceSize())
+		if _, err := out.Write(aead.Seal(nonce, nonce, chunk[:n], ad)); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func decryptStream(key []byte, in *bufio.Reader, out io.Writer) error {
+	aead, _, err := streamCipher(key)
+	if err != nil {
+		return err
+	}
+
+	nonceSize := aead.NonceSize()
+	chunk := make([]byte, nonceSize+streamChunkSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(in, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		if n < nonceSize+aead.Overhead() {
+			return fmt.Errorf("encrypted stream truncated at chunk %d", counter)
+		}
+
+		last, err := atEOF(in)
+		if err != nil {
+			return err
+		}
+
+		result, err := aead.Open(nil, chunk[:nonceSize], chunk[nonceSize:n], streamAD(counter, last))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt chunk %d: %w", counter, err)
+		}
+
+		if _, err := out.Write(result); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func streamCipher(key []byte) (cipher.AEAD, []byte, error) {
+	kdf := hkdf.New(sha256.New, key, nil, []byte(streamKdfInfo))
+	encKey, macKey := make([]byte, 32), make([]byte, 32)
+	if _, err := io.ReadFull(kdf, encKey); err != nil {
+		return nil, nil, err
+	}
+	if _, err := io.ReadFull(kdf, macKey); err != nil {
+		return nil, nil, err
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	return aead, macKey, err
+}
+
+func streamAD(counter uint64, last bool) []byte {
+	ad := make([]byte, 9)
+	binary.BigEndian.PutUint64(ad, counter)
+	if last {
+		ad[8] = 1
+	}
+	return ad
+}
+
+func streamNonce(macKey, ad, chunk []byte, size int) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(ad)
+	mac.Write(chunk)
+	return mac.Sum(nil)[:size]
+}
+
+func atEOF(in *bufio.Reader) (bool, error) {
+	_, err := in.Peek(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+func writeAll(out io.Writer, chunks ...[]byte) error {
+	for _, chunk := range chunks {
+		if _, err := out.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
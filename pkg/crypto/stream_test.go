@@ -0,0 +1,185 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func testProvider(t *testing.T) Provider {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return &KeyProvider{key: base64.StdEncoding.EncodeToString(key)}
+}
+
+func testData(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encryptBytes(t *testing.T, prov Provider, data []byte) []byte {
+	var out bytes.Buffer
+	if err := EncryptTo(prov, bytes.NewReader(data), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decryptBytes(prov Provider, data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := DecryptTo(prov, bytes.NewReader(data), &out)
+	return out.Bytes(), err
+}
+
+// chunks splits the body of a chunked file into its sealed chunks
+func chunks(t *testing.T, encrypted []byte) [][]byte {
+	header := append(append([]byte{}, Prefix...), streamHeader...)
+	if !bytes.HasPrefix(encrypted, header) {
+		t.Fatal("expected the chunked format")
+	}
+
+	body := encrypted[len(header):]
+	sealed := make([][]byte, 0)
+	for size := 12 + streamChunkSize + 16; len(body) > 0; {
+		if len(body) < size {
+			size = len(body)
+		}
+		sealed = append(sealed, body[:size])
+		body = body[size:]
+	}
+	return sealed
+}
+
+func join(t *testing.T, sealed [][]byte) []byte {
+	result := append(append([]byte{}, Prefix...), streamHeader...)
+	for _, chunk := range sealed {
+		result = append(result, chunk...)
+	}
+	return result
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	prov := testProvider(t)
+	sizes := []int{
+		0,
+		1,
+		streamThreshold,
+		streamThreshold + 1,
+		streamThreshold + streamChunkSize,
+		streamThreshold + streamChunkSize - 1,
+		streamThreshold + streamChunkSize + 1,
+		3*streamThreshold + 7,
+	}
+
+	for _, size := range sizes {
+		data := testData(t, size)
+		encrypted := encryptBytes(t, prov, data)
+
+		chunked := bytes.HasPrefix(encrypted[len(Prefix):], streamHeader)
+		if chunked != (size > streamThreshold) {
+			t.Errorf("size %d: chunked = %v", size, chunked)
+		}
+
+		if again := encryptBytes(t, prov, data); !bytes.Equal(encrypted, again) {
+			t.Errorf("size %d: encryption isn't deterministic", size)
+		}
+
+		if twice := encryptBytes(t, prov, encrypted); !bytes.Equal(encrypted, twice) {
+			t.Errorf("size %d: encrypted content wasn't passed through", size)
+		}
+
+		decrypted, err := decryptBytes(prov, encrypted)
+		if err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+		if !bytes.Equal(data, decrypted) {
+			t.Errorf("size %d: round trip changed the content", size)
+		}
+	}
+}
+
+func TestStreamChunkBoundaries(t *testing.T) {
+	prov := testProvider(t)
+	data := testData(t, 20*streamChunkSize)
+	sealed := chunks(t, encryptBytes(t, prov, data))
+	if len(sealed) != 20 {
+		t.Fatalf("expected 20 chunks, got %d", len(sealed))
+	}
+
+	for _, chunk := range sealed {
+		if len(chunk) != 12+streamChunkSize+16 {
+			t.Fatalf("chunk of %d bytes", len(chunk))
+		}
+	}
+}
+
+func TestStreamTruncation(t *testing.T) {
+	prov := testProvider(t)
+	encrypted := encryptBytes(t, prov, testData(t, streamThreshold+2*streamChunkSize+10))
+	sealed := chunks(t, encrypted)
+
+	if _, err := decryptBytes(prov, join(t, sealed[:len(sealed)-1])); err == nil {
+		t.Error("dropping the final chunk wasn't detected")
+	}
+
+	if _, err := decryptBytes(prov, encrypted[:len(encrypted)-5]); err == nil {
+		t.Error("truncating the final chunk wasn't detected")
+	}
+
+	if _, err := decryptBytes(prov, encrypted[:len(Prefix)+len(streamHeader)+10]); err == nil {
+		t.Error("truncating the first chunk wasn't detected")
+	}
+}
+
+func TestStreamReordering(t *testing.T) {
+	prov := testProvider(t)
+	sealed := chunks(t, encryptBytes(t, prov, testData(t, streamThreshold+2*streamChunkSize)))
+
+	swapped := append([][]byte{}, sealed...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if _, err := decryptBytes(prov, join(t, swapped)); err == nil {
+		t.Error("swapping chunks wasn't detected")
+	}
+
+	dropped := append(append([][]byte{}, sealed[:3]...), sealed[4:]...)
+	if _, err := decryptBytes(prov, join(t, dropped)); err == nil {
+		t.Error("dropping a middle chunk wasn't detected")
+	}
+}
+
+func TestStreamLegacyFormat(t *testing.T) {
+	prov := testProvider(t)
+	for _, size := range []int{0, 100, streamThreshold + streamChunkSize} {
+		data := testData(t, size)
+		sealed, err := Encrypt(prov, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := decryptBytes(prov, append(append([]byte{}, Prefix...), sealed...))
+		if err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+		if !bytes.Equal(data, decrypted) {
+			t.Errorf("size %d: legacy file decrypted to different content", size)
+		}
+	}
+}
+
+func TestStreamPlaintextPassthrough(t *testing.T) {
+	prov := testProvider(t)
+	data := []byte("not encrypted")
+	decrypted, err := decryptBytes(prov, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, decrypted) {
+		t.Error("plaintext wasn't passed through")
+	}
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+type VerifyStatus string
+
+const (
+	Encrypted  VerifyStatus = "encrypted"
+	Plaintext  VerifyStatus = "plaintext"
+	UnknownKey VerifyStatus = "unknown key"
+)
+
+type Verification struct {
+	File   string
+	Status VerifyStatus
+}
+
+// Verify checks the blobs in the git index (rather than the smudged working tree) for every file
+// matched by the plural-crypt filter, ensuring each is encrypted and decryptable by prov
+func Verify(root string, prov Provider) ([]*Verification, error) {
+	files, err := git.Tracked(root)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := git.Attribute(root, "filter", files)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Verification, 0)
+	for _, file := range files {
+		if filters[file] != cryptFilter {
+			continue
+		}
+
+		blob, err := git.IndexBlob(root, file)
+		if err != nil {
+			return nil, err
+		}
+
+		// an empty blob can't leak anything
+		if len(blob) == 0 {
+			continue
+		}
+
+		results = append(results, &Verification{File: file, Status: verifyBlob(prov, blob)})
+	}
+
+	return results, nil
+}
+
+func verifyBlob(prov Provider, blob []byte) VerifyStatus {
+	if !bytes.HasPrefix(blob, Prefix) {
+		return Plaintext
+	}
+
+	if err := DecryptTo(prov, bytes.NewReader(blob), ioutil.Discard); err != nil {
+		return UnknownKey
+	}
+
+	return Encrypted
+}
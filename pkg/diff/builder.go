@@ -96,8 +96,8 @@ func DefaultDiff(path string, prev *Diff) (e *Diff) {
 			Name:    "terraform-init",
 			Wkdir:   pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
 			Target:  pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
-			Command: "terraform",
-			Args:    []string{"init"},
+			Command: "plural",
+			Args:    []string{"wkspace", "terraform-init"},
 			Sha:     "",
 		},
 		{
@@ -3,28 +3,41 @@ package executor
 import (
 	"path/filepath"
 
+	"github.com/pluralsh/plural/pkg/policy"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 )
 
-func defaultSteps(path string) []*Step {
+// steps that block a deploy on policy violations, only added for workspaces enforcing their policies
+var policySteps = map[string]bool{"terraform-policy": true, "helm-policy": true}
+
+func defaultSteps(path string, policies bool) []*Step {
 	app := pathing.SanitizeFilepath(filepath.Base(path))
 	sanitizedPath := pathing.SanitizeFilepath(path)
 
-	return []*Step{
+	steps := []*Step{
 		{
 			Name:    "terraform-init",
 			Wkdir:   pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
 			Target:  pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
-			Command: "terraform",
-			Args:    []string{"init", "-upgrade"},
+			Command: "plural",
+			Args:    []string{"wkspace", "terraform-init", "--upgrade"},
+			Sha:     "",
+		},
+		{
+			Name:    "terraform-policy",
+			Wkdir:   sanitizedPath,
+			Target:  pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
+			Command: "plural",
+			Include: []string{policy.Dir},
+			Args:    []string{"policy", "check", app, "--only", "terraform"},
 			Sha:     "",
 		},
 		{
 			Name:    "terraform-apply",
 			Wkdir:   pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
 			Target:  pathing.SanitizeFilepath(filepath.Join(path, "terraform")),
-			Command: "terraform",
-			Args:    []string{"apply", "-auto-approve"},
+			Command: "plural",
+			Args:    []string{"wkspace", "terraform-apply"},
 			Sha:     "",
 			Retries: 1,
 		},
@@ -52,6 +65,15 @@ func defaultSteps(path string) []*Step {
 			Args:    []string{"wkspace", "crds", sanitizedPath},
 			Sha:     "",
 		},
+		{
+			Name:    "helm-policy",
+			Wkdir:   sanitizedPath,
+			Target:  pathing.SanitizeFilepath(filepath.Join(path, "helm")),
+			Command: "plural",
+			Include: []string{policy.Dir},
+			Args:    []string{"policy", "check", app, "--only", "helm", "--skip-deps"},
+			Sha:     "",
+		},
 		{
 			Name:    "bounce",
 			Wkdir:   sanitizedPath,
@@ -62,4 +84,20 @@ func defaultSteps(path string) []*Step {
 			Retries: 1,
 		},
 	}
+
+	if policies {
+		return steps
+	}
+
+	return withoutPolicySteps(steps)
+}
+
+func withoutPolicySteps(steps []*Step) []*Step {
+	result := make([]*Step, 0, len(steps))
+	for _, step := range steps {
+		if !policySteps[step.Name] {
+			result = append(result, step)
+		}
+	}
+	return result
 }
@@ -97,9 +97,13 @@ func (e *Execution) IgnoreFile(root string) ([]string, error) {
 	return result, nil
 }
 
-func DefaultExecution(path string, prev *Execution) (e *Execution) {
+// DefaultExecution merges the default deploy steps into prev, including the policy checks if policies is set
+func DefaultExecution(path string, prev *Execution, policies bool) (e *Execution) {
 	byName := make(map[string]*Step)
-	steps := defaultSteps(path)
+	steps := defaultSteps(path, policies)
+	if !policies {
+		prev.Steps = withoutPolicySteps(prev.Steps)
+	}
 
 	for _, step := range prev.Steps {
 		byName[step.Name] = step
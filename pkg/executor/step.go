@@ -1,6 +1,8 @@
 package executor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -31,18 +33,29 @@ type Step struct {
 	Sha     string   `hcl:"sha"`
 	Retries int      `hcl:"retries"`
 	Verbose bool     `hcl:"verbose"`
+	// other paths, relative to the workspace root, whose changes also rerun the step
+	Include []string `hcl:"include" hcle:"omitempty"`
+}
+
+func SuppressedOutput() *OutputWriter {
+	return &OutputWriter{delegate: os.Stdout}
 }
 
 func SuppressedCommand(command string, args ...string) (cmd *exec.Cmd, output *OutputWriter) {
 	cmd = exec.Command(command, args...)
-	output = &OutputWriter{delegate: os.Stdout}
+	output = SuppressedOutput()
 	cmd.Stdout = output
 	cmd.Stderr = output
 	return
 }
 
 func RunCommand(cmd *exec.Cmd, output *OutputWriter) (err error) {
-	err = cmd.Run()
+	return RunFunc(cmd.Run, output)
+}
+
+// RunFunc reports the result of an in-process operation writing to output like RunCommand does for a subprocess
+func RunFunc(fn func() error, output *OutputWriter) (err error) {
+	err = fn()
 	if err != nil {
 		out := output.Format()
 		fmt.Printf("\nOutput:\n\n%s\n", out)
@@ -71,7 +84,7 @@ func (step Step) Run(root string) error {
 }
 
 func (step Step) Execute(root string, ignore []string) (string, error) {
-	current, err := MkHash(pathing.SanitizeFilepath(filepath.Join(root, step.Target)), ignore)
+	current, err := step.hash(root, ignore)
 	if err != nil {
 		return step.Sha, err
 	}
@@ -96,6 +109,30 @@ func (step Step) Execute(root string, ignore []string) (string, error) {
 	return current, err
 }
 
+// hash is the hash of the step's target, combined with those of any included paths that exist
+func (step Step) hash(root string, ignore []string) (string, error) {
+	current, err := MkHash(pathing.SanitizeFilepath(filepath.Join(root, step.Target)), ignore)
+	if err != nil || len(step.Include) == 0 {
+		return current, err
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(current))
+	for _, include := range step.Include {
+		path := pathing.SanitizeFilepath(filepath.Join(root, include))
+		if !utils.Exists(path) {
+			continue
+		}
+
+		sha, err := MkHash(path, ignore)
+		if err != nil {
+			return "", err
+		}
+		hasher.Write([]byte(include + ":" + sha))
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
 func MkHash(root string, ignore []string) (string, error) {
 	fi, err := os.Stat(root)
 	if err != nil {
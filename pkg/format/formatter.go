@@ -12,12 +12,15 @@ type FormatType string
 const (
 	CsvFormat FormatType = "csv"
 	TableFormat FormatType = "table"
+	JsonFormat FormatType = "json"
 )
 
 func New(format FormatType) Formatter {
 	switch format {
 	case CsvFormat:
 		return NewCsvFormatter()
+	case JsonFormat:
+		return NewJsonFormatter()
 	default:
 		return NewTableFormatter()
 	}
@@ -0,0 +1,47 @@
+package format
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// jsonFormatter buffers every line and prints them as a list of objects keyed by the header on Flush
+type jsonFormatter struct {
+	header []string
+	rows   []map[string]string
+}
+
+func NewJsonFormatter() *jsonFormatter {
+	return &jsonFormatter{rows: make([]map[string]string, 0)}
+}
+
+func (f *jsonFormatter) Write(line []string) error {
+	row := make(map[string]string, len(line))
+	for i, val := range line {
+		if i < len(f.header) {
+			row[f.header[i]] = val
+		}
+	}
+	f.rows = append(f.rows, row)
+	return nil
+}
+
+func (f *jsonFormatter) Dump(lines [][]string) error {
+	for _, line := range lines {
+		if err := f.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *jsonFormatter) Flush() error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(f.rows)
+}
+
+func (f *jsonFormatter) Header(line []string) {
+	f.header = line
+}
@@ -2,7 +2,7 @@ package helm
 
 
 import (
-	"os"
+	"io"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/downloader"
@@ -12,8 +12,8 @@ import (
 
 var providers = []getter.Provider{ ChartMuseumProvider }
 
-func UpdateDependencies(path string) error {
-	out := os.Stdout
+// UpdateDependencies downloads the dependencies of the chart at path, writing progress to out
+func UpdateDependencies(path string, out io.Writer) error {
 	client := action.NewDependency()
 	settings := cli.New()
 
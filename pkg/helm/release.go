@@ -0,0 +1,234 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"helm.sh/helm/v3/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+// ErrReleaseNotFound is returned (wrapped) by any operation on a release that was never installed
+var ErrReleaseNotFound = driver.ErrReleaseNotFound
+
+type UpgradeOptions struct {
+	// overrides in helm's --set syntax, eg postgres.enabled=false
+	Set      []string
+	SkipCRDs bool
+	Wait     bool
+	Timeout  time.Duration
+}
+
+// ResourceChange describes what an upgrade did to a single resource in a release
+type ResourceChange struct {
+	Resource string
+	Action   string
+}
+
+func actionConfig(namespace string) (*action.Configuration, error) {
+	settings := cli.New()
+	settings.SetNamespace(namespace)
+	conf := new(action.Configuration)
+	logger := func(format string, v ...interface{}) {}
+	if err := conf.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), logger); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Upgrade installs the chart at path as release name, or upgrades it if it's already installed, returning
+// a change for every resource the release now manages or has stopped managing
+func Upgrade(namespace, name, path string, opts *UpgradeOptions) ([]*ResourceChange, error) {
+	conf, err := actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, vals, err := loadChart(path, opts.Set)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	previous, err := action.NewGet(conf).Run(name)
+	if err != nil && !errors.Is(err, ErrReleaseNotFound) {
+		return nil, err
+	}
+
+	var rel *release.Release
+	if previous == nil {
+		install := action.NewInstall(conf)
+		install.ReleaseName = name
+		install.Namespace = namespace
+		install.SkipCRDs = opts.SkipCRDs
+		install.Wait = opts.Wait
+		install.Timeout = timeout
+		rel, err = install.Run(ch, vals)
+	} else {
+		upgrade := action.NewUpgrade(conf)
+		upgrade.Namespace = namespace
+		upgrade.SkipCRDs = opts.SkipCRDs
+		upgrade.Wait = opts.Wait
+		upgrade.Timeout = timeout
+		rel, err = upgrade.Run(name, ch, vals)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before := map[string]string{}
+	if previous != nil {
+		before = manifestResources(previous.Manifest)
+	}
+	return resourceChanges(before, manifestResources(rel.Manifest)), nil
+}
+
+// Uninstall deletes a release, returning an error wrapping ErrReleaseNotFound if it isn't installed
+func Uninstall(namespace, name string) error {
+	conf, err := actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	_, err = action.NewUninstall(conf).Run(name)
+	return err
+}
+
+// Diff writes a unified diff per resource between the deployed release and a dry run upgrade of the chart at path
+func Diff(namespace, name, path string, set []string, out io.Writer) error {
+	conf, err := actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	ch, vals, err := loadChart(path, set)
+	if err != nil {
+		return err
+	}
+
+	before := map[string]string{}
+	previous, err := action.NewGet(conf).Run(name)
+	switch {
+	case errors.Is(err, ErrReleaseNotFound):
+	case err != nil:
+		return err
+	default:
+		before = manifestResources(previous.Manifest)
+	}
+
+	var rel *release.Release
+	if previous == nil {
+		install := action.NewInstall(conf)
+		install.ReleaseName = name
+		install.Namespace = namespace
+		install.DryRun = true
+		rel, err = install.Run(ch, vals)
+	} else {
+		upgrade := action.NewUpgrade(conf)
+		upgrade.Namespace = namespace
+		upgrade.DryRun = true
+		// diff against the chart's own values, like `helm diff upgrade --reset-values` did, rather than the release's
+		upgrade.ResetValues = true
+		rel, err = upgrade.Run(name, ch, vals)
+	}
+	if err != nil {
+		return err
+	}
+
+	after := manifestResources(rel.Manifest)
+	for _, resource := range resourceNames(before, after) {
+		diff := difflib.UnifiedDiff{
+			A:        difflib.SplitLines(before[resource]),
+			B:        difflib.SplitLines(after[resource]),
+			FromFile: "deployed " + resource,
+			ToFile:   "local " + resource,
+			Context:  3,
+		}
+		if err := difflib.WriteUnifiedDiff(out, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadChart(path string, set []string) (*chart.Chart, map[string]interface{}, error) {
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vals := map[string]interface{}{}
+	for _, val := range set {
+		if err := strvals.ParseInto(val, vals); err != nil {
+			return nil, nil, fmt.Errorf("invalid value override %s: %w", val, err)
+		}
+	}
+	return ch, vals, nil
+}
+
+// manifestResources splits a release manifest into documents keyed by kind and name
+func manifestResources(manifest string) map[string]string {
+	resources := map[string]string{}
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(doc), &head); err != nil || head.Metadata == nil {
+			continue
+		}
+
+		resources[fmt.Sprintf("%s/%s", head.Kind, head.Metadata.Name)] = doc
+	}
+	return resources
+}
+
+func resourceChanges(before, after map[string]string) []*ResourceChange {
+	changes := make([]*ResourceChange, 0)
+	for _, resource := range resourceNames(before, after) {
+		old, existed := before[resource]
+		current, exists := after[resource]
+		switch {
+		case !existed:
+			changes = append(changes, &ResourceChange{Resource: resource, Action: "created"})
+		case !exists:
+			changes = append(changes, &ResourceChange{Resource: resource, Action: "deleted"})
+		case strings.TrimSpace(old) != strings.TrimSpace(current):
+			changes = append(changes, &ResourceChange{Resource: resource, Action: "configured"})
+		default:
+			changes = append(changes, &ResourceChange{Resource: resource, Action: "unchanged"})
+		}
+	}
+	return changes
+}
+
+func resourceNames(manifests ...map[string]string) []string {
+	seen := map[string]bool{}
+	names := make([]string, 0)
+	for _, manifest := range manifests {
+		for name := range manifest {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,145 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is every resource rendered from a single template file of a chart
+type Manifest struct {
+	Source  string
+	Content string
+}
+
+// ManifestError is a resource that doesn't match the schema of its kind
+type ManifestError struct {
+	Source   string
+	Resource string
+	Message  string
+}
+
+type RenderOptions struct {
+	// defaults to the version the helm sdk was built against
+	KubeVersion string
+}
+
+// Render templates the chart at path entirely client side, so it needs no cluster access, returning
+// the rendered manifests grouped by the template file they came from
+func Render(namespace, name, path string, vals map[string]interface{}, opts *RenderOptions) ([]*Manifest, error) {
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(&action.Configuration{})
+	install.ReleaseName = name
+	install.Namespace = namespace
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = true
+	if opts.KubeVersion != "" {
+		install.KubeVersion, err = chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rel, err := install.Run(ch, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	// every hook in a template file shares its path, so they're numbered after the manifests instead
+	docs := releaseutil.SplitManifests(rel.Manifest)
+	count := len(docs)
+	for i, hook := range rel.Hooks {
+		docs[fmt.Sprintf("manifest-%d", count+i)] = fmt.Sprintf("# Source: %s\n%s", hook.Path, hook.Manifest)
+	}
+
+	bySource := map[string][]string{}
+	for _, key := range sortedKeys(docs) {
+		doc := strings.TrimSpace(docs[key])
+		source := manifestSource(doc)
+		bySource[source] = append(bySource[source], doc)
+	}
+
+	manifests := make([]*Manifest, 0, len(bySource))
+	for source, docs := range bySource {
+		manifests = append(manifests, &Manifest{Source: source, Content: strings.Join(docs, "\n---\n") + "\n"})
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Source < manifests[j].Source })
+	return manifests, nil
+}
+
+// Conform strictly decodes every resource of a built in kind in manifests, reporting unknown fields and type
+// mismatches.  Resources of kinds the kubernetes client doesn't know, like custom resources, are returned as skipped.
+func Conform(manifests []*Manifest) (errs []*ManifestError, skipped []string) {
+	decoder := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{Yaml: true, Strict: true})
+	errs = make([]*ManifestError, 0)
+	skipped = make([]string, 0)
+	for _, manifest := range manifests {
+		for _, doc := range releaseutil.SplitManifests(manifest.Content) {
+			var head releaseutil.SimpleHead
+			if err := yaml.Unmarshal([]byte(doc), &head); err != nil {
+				errs = append(errs, &ManifestError{Source: manifest.Source, Message: err.Error()})
+				continue
+			}
+
+			if head.Kind == "" {
+				continue
+			}
+
+			resource := head.Kind
+			if head.Metadata != nil {
+				resource = fmt.Sprintf("%s/%s", head.Kind, head.Metadata.Name)
+			}
+
+			gvk := schema.FromAPIVersionAndKind(head.Version, head.Kind)
+			if !scheme.Scheme.Recognizes(gvk) {
+				skipped = append(skipped, resource)
+				continue
+			}
+
+			if _, _, err := decoder.Decode([]byte(doc), &gvk, nil); err != nil {
+				errs = append(errs, &ManifestError{Source: manifest.Source, Resource: resource, Message: strictMessage(err)})
+			}
+		}
+	}
+	return
+}
+
+func strictMessage(err error) string {
+	if runtime.IsStrictDecodingError(err) {
+		return strings.TrimPrefix(err.Error(), "strict decoding error: ")
+	}
+	return err.Error()
+}
+
+func manifestSource(doc string) string {
+	line := strings.SplitN(doc, "\n", 2)[0]
+	if strings.HasPrefix(line, "# Source: ") {
+		return strings.TrimPrefix(line, "# Source: ")
+	}
+	return "manifest.yaml"
+}
+
+func sortedKeys(docs map[string]string) []string {
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	return keys
+}
@@ -0,0 +1,159 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+// ValueError is a problem with a single value, Path is dot separated from the top of the chart's values
+type ValueError struct {
+	Path    string
+	Message string
+}
+
+// ValidateValues checks vals against the values.schema.json of the chart at path and each of its enabled
+// dependencies, returning schema violations along with any keys none of the charts define a default for
+func ValidateValues(path string, vals map[string]interface{}) (invalid []*ValueError, unknown []*ValueError, err error) {
+	ch, err := loader.Load(path)
+	if err != nil {
+		return
+	}
+
+	if err = chartutil.ProcessDependencies(ch, vals); err != nil {
+		return
+	}
+
+	coalesced, err := chartutil.CoalesceValues(ch, vals)
+	if err != nil {
+		return
+	}
+
+	invalid, err = validateSchemas(ch, coalesced, "")
+	if err != nil {
+		return
+	}
+
+	unknown = make([]*ValueError, 0)
+	for _, dep := range ch.Dependencies() {
+		if sub, ok := vals[dep.Name()].(map[string]interface{}); ok {
+			unknown = append(unknown, unknownKeys(dep, sub, dep.Name())...)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Path < unknown[j].Path })
+	return
+}
+
+func validateSchemas(ch *chart.Chart, vals map[string]interface{}, prefix string) ([]*ValueError, error) {
+	errs := make([]*ValueError, 0)
+	if ch.Schema != nil {
+		doc, err := yaml.Marshal(vals)
+		if err != nil {
+			return nil, err
+		}
+
+		valsJSON, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(ch.Schema), gojsonschema.NewBytesLoader(valsJSON))
+		if err != nil {
+			return nil, fmt.Errorf("invalid values.schema.json in chart %s: %w", ch.Name(), err)
+		}
+
+		for _, desc := range result.Errors() {
+			errs = append(errs, &ValueError{Path: schemaPath(prefix, desc.Field()), Message: desc.Description()})
+		}
+	}
+
+	for _, dep := range ch.Dependencies() {
+		sub, _ := vals[dep.Name()].(map[string]interface{})
+		depErrs, err := validateSchemas(dep, sub, joinPath(prefix, dep.Name()))
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, depErrs...)
+	}
+	return errs, nil
+}
+
+// unknownKeys finds keys in vals the chart has no default for.  Maps the chart defaults to empty, like
+// podAnnotations, are free form so anything under them is allowed.
+func unknownKeys(ch *chart.Chart, vals map[string]interface{}, prefix string) []*ValueError {
+	known := map[string]bool{"global": true, "enabled": true}
+	subcharts := map[string]*chart.Chart{}
+	for _, dep := range ch.Dependencies() {
+		subcharts[dep.Name()] = dep
+	}
+
+	errs := make([]*ValueError, 0)
+	for key, val := range vals {
+		path := joinPath(prefix, key)
+		if sub, ok := subcharts[key]; ok {
+			if subVals, ok := val.(map[string]interface{}); ok {
+				errs = append(errs, unknownKeys(sub, subVals, path)...)
+			}
+			continue
+		}
+
+		if known[key] {
+			continue
+		}
+
+		def, ok := ch.Values[key]
+		if !ok {
+			errs = append(errs, &ValueError{Path: path, Message: fmt.Sprintf("chart %s doesn't define this value", ch.Name())})
+			continue
+		}
+
+		errs = append(errs, unknownDefaults(ch, def, val, path)...)
+	}
+	return errs
+}
+
+func unknownDefaults(ch *chart.Chart, def, val interface{}, path string) []*ValueError {
+	defMap, ok := def.(map[string]interface{})
+	if !ok || len(defMap) == 0 {
+		return nil
+	}
+
+	valMap, ok := val.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	errs := make([]*ValueError, 0)
+	for key, v := range valMap {
+		sub, ok := defMap[key]
+		if !ok {
+			errs = append(errs, &ValueError{Path: joinPath(path, key), Message: fmt.Sprintf("chart %s doesn't define this value", ch.Name())})
+			continue
+		}
+		errs = append(errs, unknownDefaults(ch, sub, v, joinPath(path, key))...)
+	}
+	return errs
+}
+
+// gojsonschema reports fields like (root).persistence.size
+func schemaPath(prefix, field string) string {
+	field = strings.TrimPrefix(strings.TrimPrefix(field, "(root)"), ".")
+	return joinPath(prefix, field)
+}
+
+func joinPath(prefix, key string) string {
+	switch {
+	case prefix == "":
+		return key
+	case key == "":
+		return prefix
+	default:
+		return prefix + "." + key
+	}
+}
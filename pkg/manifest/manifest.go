@@ -108,6 +108,36 @@ func Read(path string) (man *Manifest, err error) {
 	return
 }
 
+// Privileged is true if repo is trusted to use privileged template functions
+func (man *ProjectManifest) Privileged(repo string) bool {
+	if man == nil {
+		return false
+	}
+
+	for _, r := range man.PrivilegedRepos {
+		if r == repo {
+			return true
+		}
+	}
+	return false
+}
+
+// Trust adds repo to the privileged repos of the workspace
+func Trust(repo string) error {
+	path := ProjectManifestPath()
+	man, err := ReadProject(path)
+	if err != nil {
+		return err
+	}
+
+	if man.Privileged(repo) {
+		return nil
+	}
+
+	man.PrivilegedRepos = append(man.PrivilegedRepos, repo)
+	return man.Write(path)
+}
+
 func (man *ProjectManifest) Configure() Writer {
 	utils.Highlight("\nLet's get some final information about your workspace set up\n\n")
 
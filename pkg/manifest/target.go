@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/pluralsh/plural/pkg/config"
+)
+
+const PrimaryTarget = "primary"
+
+// Target finds the cluster target with the given name, returning nil for the primary cluster
+func (man *ProjectManifest) Target(name string) (*ClusterTarget, error) {
+	if name == "" || name == PrimaryTarget {
+		return nil, nil
+	}
+
+	for _, target := range man.Targets {
+		if target.Name == name {
+			return target, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No cluster target named %s in workspace.yaml", name)
+}
+
+// TargetConfig reads the cli config, with the namespace prefix overridden if the target cluster sets its own
+func (man *ProjectManifest) TargetConfig(name string) (config.Config, error) {
+	conf := config.Read()
+	target, err := man.Target(name)
+	if err != nil {
+		return conf, err
+	}
+
+	if target != nil && target.NamespacePrefix != "" {
+		conf.NamespacePrefix = target.NamespacePrefix
+	}
+	return conf, nil
+}
+
+// RepoConfig is the config of the cluster target an installed repo deploys to, or just the cli config outside a
+// workspace
+func RepoConfig(repo string) config.Config {
+	project, err := FetchProject()
+	if err != nil {
+		return config.Read()
+	}
+
+	conf, _ := project.TargetConfig(RepoTarget(repo))
+	return conf
+}
+
+// ForTarget returns a copy of the manifest describing the named target's cluster, so it can be handed
+// to anything expecting a single cluster workspace.  Ownership and network config are shared by every target.
+func (man *ProjectManifest) ForTarget(name string) (*ProjectManifest, error) {
+	target, err := man.Target(name)
+	if err != nil || target == nil {
+		return man, err
+	}
+
+	return &ProjectManifest{
+		Cluster:      target.Cluster,
+		Bucket:       target.Bucket,
+		Project:      target.Project,
+		Provider:     target.Provider,
+		Region:       target.Region,
+		Owner:        man.Owner,
+		Network:      man.Network,
+		BucketPrefix: man.BucketPrefix,
+		Context:      target.Context,
+
+		TerraformVersion: man.TerraformVersion,
+		Environment:      man.Environment,
+		EnforcePolicies:  man.EnforcePolicies,
+		PrivilegedRepos:  man.PrivilegedRepos,
+	}, nil
+}
+
+// RepoTarget returns the name of the cluster target an installed repo deploys to
+func RepoTarget(repo string) string {
+	path, err := ManifestPath(repo)
+	if err != nil {
+		return PrimaryTarget
+	}
+
+	man, err := Read(path)
+	if err != nil || man.Target == "" {
+		return PrimaryTarget
+	}
+
+	return man.Target
+}
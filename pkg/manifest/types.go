@@ -36,6 +36,7 @@ type Manifest struct {
 	Dependencies []*Dependency
 	Context      map[string]interface{}
 	Links        *Links `yaml:"links,omitempty"`
+	Target       string `yaml:"target,omitempty"`
 }
 
 type Owner struct {
@@ -58,6 +59,28 @@ type ProjectManifest struct {
 	Network      *NetworkConfig
 	BucketPrefix string `yaml:"bucketPrefix"`
 	Context      map[string]interface{}
+	Targets      []*ClusterTarget `yaml:"targets,omitempty"`
+	// terraform version every repo in the workspace is planned and applied with, installed on demand
+	TerraformVersion string `yaml:"terraformVersion,omitempty"`
+	// selects the values.<environment>.yaml overlay applied to each repo's helm values
+	Environment string `yaml:"environment,omitempty"`
+	// adds steps to every repo's deploy that block it on violations of the policies in the policies directory
+	EnforcePolicies bool `yaml:"enforcePolicies,omitempty"`
+	// repos trusted to use privileged template functions, which read local files and secrets or prompt for input
+	PrivilegedRepos []string `yaml:"privilegedRepos,omitempty"`
+}
+
+// ClusterTarget is an additional cluster a workspace can deploy repos to, alongside the
+// primary one described by the top level fields of the ProjectManifest
+type ClusterTarget struct {
+	Name            string
+	Cluster         string
+	Bucket          string
+	Project         string
+	Provider        string
+	Region          string
+	NamespacePrefix string `yaml:"namespacePrefix,omitempty"`
+	Context         map[string]interface{}
 }
 
 type VersionedManifest struct {
@@ -1,32 +1,14 @@
 package output
 
 import (
-	"encoding/json"
-	"os/exec"
+	"github.com/pluralsh/plural/pkg/terraform"
 )
 
-type TerraformOutputItem struct {
-	Value interface{}
-	Type  interface{}
-}
-
-func TerraformOutput(path string) (out map[string]interface{}, err error) {
-	cmd := exec.Command("terraform", "output", "-json")
-	cmd.Dir = path
-	res, err := cmd.Output()
-	if err != nil {
-		return
-	}
-
-	outType := make(map[string]TerraformOutputItem)
-	err = json.Unmarshal(res, &outType)
+func TerraformOutput(path string) (map[string]interface{}, error) {
+	tf, err := terraform.New(path)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	out = make(map[string]interface{})
-	for key, val := range outType {
-		out[key] = val.Value
-	}
-	return
+	return tf.Output()
 }
@@ -0,0 +1,195 @@
+package overlay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ValuesFile is what helm actually installs, the merge of every layer
+	ValuesFile    = "values.yaml"
+	GeneratedFile = "values.generated.yaml"
+	OverrideFile  = "values.override.yaml"
+)
+
+const (
+	GeneratedLayer = "generated"
+	OverrideLayer  = "override"
+)
+
+// Layer is a single source of helm values, later layers take precedence over earlier ones
+type Layer struct {
+	Name   string
+	Path   string
+	Values map[string]interface{}
+}
+
+// Setting is the value a layer sets at some key path
+type Setting struct {
+	Layer *Layer
+	Value interface{}
+}
+
+func EnvironmentFile(env string) string {
+	return fmt.Sprintf("values.%s.yaml", env)
+}
+
+// Layers returns the layers of the chart in dir in precedence order: freshly generated values, then the
+// overlay for env if there is one, then the user owned override file
+func Layers(dir, env string, generated map[string]interface{}) ([]*Layer, error) {
+	layers := []*Layer{{Name: GeneratedLayer, Path: pathing.SanitizeFilepath(filepath.Join(dir, GeneratedFile)), Values: generated}}
+	files := []struct{ name, file string }{{OverrideLayer, OverrideFile}}
+	if env != "" {
+		files = append([]struct{ name, file string }{{env, EnvironmentFile(env)}}, files...)
+	}
+
+	for _, f := range files {
+		path := pathing.SanitizeFilepath(filepath.Join(dir, f.file))
+		if !utils.Exists(path) {
+			continue
+		}
+
+		vals, err := Read(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, &Layer{Name: f.name, Path: path, Values: vals})
+	}
+	return layers, nil
+}
+
+// Load reads every layer of the chart in dir, using the generated values from the last build
+func Load(dir, env string) ([]*Layer, error) {
+	generated, err := Read(pathing.SanitizeFilepath(filepath.Join(dir, GeneratedFile)))
+	if err != nil {
+		return nil, err
+	}
+	return Layers(dir, env, generated)
+}
+
+func Read(path string) (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals map[interface{}]interface{}
+	if err := yaml.Unmarshal(contents, &vals); err != nil {
+		return nil, fmt.Errorf("invalid yaml in %s: %w", path, err)
+	}
+	return normalizeMap(vals), nil
+}
+
+// Merge deep merges layers in order, maps are merged key by key while any other value, including lists,
+// is replaced outright by later layers
+func Merge(layers []*Layer) map[string]interface{} {
+	res := map[string]interface{}{}
+	for _, layer := range layers {
+		merge(res, layer.Values)
+	}
+	return res
+}
+
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		switch {
+		case srcIsMap && dstIsMap:
+			merge(dstMap, srcMap)
+		case srcIsMap:
+			copied := map[string]interface{}{}
+			merge(copied, srcMap)
+			dst[k] = copied
+		default:
+			dst[k] = v
+		}
+	}
+}
+
+// Explain lists the value every layer sets at a dot separated key path, the last setting wins
+func Explain(layers []*Layer, path string) []*Setting {
+	settings := make([]*Setting, 0)
+	for _, layer := range layers {
+		if val, ok := lookup(layer.Values, strings.Split(path, ".")); ok {
+			settings = append(settings, &Setting{Layer: layer, Value: val})
+		}
+	}
+	return settings
+}
+
+// Diff returns everything in previous that generated doesn't set to the same value, used to carry hand
+// edits made to a values file over into an override layer
+func Diff(previous, generated map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for k, v := range previous {
+		prevMap, prevIsMap := v.(map[string]interface{})
+		genMap, genIsMap := generated[k].(map[string]interface{})
+		if prevIsMap && genIsMap {
+			if diff := Diff(prevMap, genMap); len(diff) > 0 {
+				res[k] = diff
+			}
+			continue
+		}
+
+		if gen, ok := generated[k]; !ok || !reflect.DeepEqual(v, gen) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func lookup(vals map[string]interface{}, path []string) (interface{}, bool) {
+	val, ok := vals[path[0]]
+	if !ok || len(path) == 1 {
+		return val, ok
+	}
+
+	next, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookup(next, path[1:])
+}
+
+// Normalize converts any maps yaml.v2 decoded within vals into string keyed ones
+func Normalize(vals map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		res[k] = normalize(v)
+	}
+	return res
+}
+
+func normalizeMap(in map[interface{}]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		res[fmt.Sprintf("%v", k)] = normalize(v)
+	}
+	return res
+}
+
+// normalize converts the maps yaml.v2 decodes into string keyed ones, leaving scalars their original type
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		return normalizeMap(v)
+	case map[string]interface{}:
+		return Normalize(v)
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, elem := range v {
+			res[i] = normalize(elem)
+		}
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/pluralsh/plural/pkg/utils"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+)
+
+// celPolicy is a cel expression every matching resource must satisfy, eg:
+//
+//	name: no-public-load-balancers
+//	target: kubernetes
+//	kinds: [Service]
+//	expression: "!has(object.spec.type) || object.spec.type != 'LoadBalancer'"
+//	message: services can't be exposed through public load balancers
+//
+// object is a kubernetes resource, or a resource_changes entry of the terraform plan, and repo is the repo it's from.
+type celPolicy struct {
+	Name       string   `json:"name"`
+	Target     string   `json:"target"`
+	Kinds      []string `json:"kinds"`
+	Expression string   `json:"expression"`
+	Message    string   `json:"message"`
+
+	program cel.Program
+}
+
+func readCel(path string) (*celPolicy, error) {
+	contents, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pol := &celPolicy{}
+	if err := yaml.Unmarshal([]byte(contents), pol); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	if pol.Name == "" {
+		pol.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if pol.Target != Kubernetes && pol.Target != Terraform {
+		return nil, fmt.Errorf("policy %s must target either %s or %s", pol.Name, Kubernetes, Terraform)
+	}
+
+	if pol.Expression == "" {
+		return nil, fmt.Errorf("policy %s has no expression", pol.Name)
+	}
+
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("object", decls.NewMapType(decls.String, decls.Dyn)),
+		decls.NewVar("repo", decls.String),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(pol.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression in policy %s: %w", pol.Name, issues.Err())
+	}
+
+	if t := ast.ResultType(); !proto.Equal(t, decls.Bool) && !proto.Equal(t, decls.Dyn) {
+		return nil, fmt.Errorf("the expression in policy %s must evaluate to a bool", pol.Name)
+	}
+
+	pol.program, err = env.Program(ast)
+	return pol, err
+}
+
+// matches is true if the policy applies to resources of kind, which is a terraform resource type for terraform policies
+func (pol *celPolicy) matches(kind string) bool {
+	if len(pol.Kinds) == 0 {
+		return true
+	}
+
+	for _, k := range pol.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// eval returns whether object passes the policy, and why not if it doesn't.  Expressions that fail to evaluate,
+// like ones reading a field the resource doesn't have, count as violations so a broken policy can't pass silently.
+func (pol *celPolicy) eval(repo string, object map[string]interface{}) (string, bool) {
+	out, _, err := pol.program.Eval(map[string]interface{}{"object": object, "repo": repo})
+	if err != nil {
+		return fmt.Sprintf("could not evaluate policy: %s", err), false
+	}
+
+	if passed, ok := out.Value().(bool); !ok || !passed {
+		return pol.message(), false
+	}
+	return "", true
+}
+
+func (pol *celPolicy) message() string {
+	if pol.Message != "" {
+		return pol.Message
+	}
+	return fmt.Sprintf("failed %s", pol.Expression)
+}
@@ -0,0 +1,215 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pluralsh/plural/pkg/helm"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+// Dir is where a workspace keeps its policies, relative to its root
+const Dir = "policies"
+
+// what a policy is evaluated against
+const (
+	Kubernetes = "kubernetes"
+	Terraform  = "terraform"
+)
+
+// Violation is a single resource breaking a policy
+type Violation struct {
+	Policy   string
+	Repo     string
+	Resource string
+	Message  string
+}
+
+// Policies are every rego and cel policy found in a workspace
+type Policies struct {
+	rego map[string]*regoTarget
+	cel  []*celPolicy
+}
+
+// Load reads the *.rego and cel *.yaml policies in dir and its subdirectories, a missing dir has no policies
+func Load(dir string) (*Policies, error) {
+	policies := &Policies{rego: map[string]*regoTarget{}, cel: make([]*celPolicy, 0)}
+	if !utils.Exists(dir) {
+		return policies, nil
+	}
+
+	modules := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		switch filepath.Ext(path) {
+		case ".rego":
+			if strings.HasSuffix(path, "_test.rego") {
+				return nil
+			}
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			modules[path] = string(contents)
+		case ".yaml", ".yml":
+			pol, err := readCel(path)
+			if err != nil {
+				return err
+			}
+			policies.cel = append(policies.cel, pol)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modules) == 0 {
+		return policies, nil
+	}
+
+	for _, target := range []string{Kubernetes, Terraform} {
+		prepared, err := prepareRego(target, modules)
+		if err != nil {
+			return nil, err
+		}
+		policies.rego[target] = prepared
+	}
+	return policies, nil
+}
+
+// LoadWorkspace loads the policies of the workspace rooted at root
+func LoadWorkspace(root string) (*Policies, error) {
+	return Load(pathing.SanitizeFilepath(filepath.Join(root, Dir)))
+}
+
+// Empty is true if there are no policies to check
+func (p *Policies) Empty() bool {
+	return len(p.cel) == 0 && len(p.rego) == 0
+}
+
+// CheckManifests evaluates the kubernetes policies against every resource rendered from a repo's helm chart
+func (p *Policies) CheckManifests(repo string, manifests []*helm.Manifest) ([]*Violation, error) {
+	violations := make([]*Violation, 0)
+	for _, manifest := range manifests {
+		for _, doc := range releaseutil.SplitManifests(manifest.Content) {
+			var object map[string]interface{}
+			if err := yaml.Unmarshal([]byte(doc), &object); err != nil {
+				return nil, fmt.Errorf("could not parse %s: %w", manifest.Source, err)
+			}
+
+			kind, _ := object["kind"].(string)
+			if kind == "" {
+				continue
+			}
+
+			resource := kind
+			if meta, ok := object["metadata"].(map[string]interface{}); ok {
+				resource = fmt.Sprintf("%s/%s", kind, meta["name"])
+			}
+
+			found, err := p.check(Kubernetes, repo, kind, resource, object)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	}
+	return sorted(violations), nil
+}
+
+// CheckPlan evaluates the terraform policies against a repo's terraform plan.  Rego policies get the whole plan as
+// their input, like `terraform show -json` prints it, while cel policies are checked against each resource change.
+func (p *Policies) CheckPlan(repo string, plan *tfjson.Plan) ([]*Violation, error) {
+	var doc map[string]interface{}
+	if err := roundTrip(plan, &doc); err != nil {
+		return nil, err
+	}
+
+	violations := make([]*Violation, 0)
+	if target, ok := p.rego[Terraform]; ok {
+		found, err := target.eval(repo, "", doc)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+
+	changes, _ := doc["resource_changes"].([]interface{})
+	for _, change := range changes {
+		object, ok := change.(map[string]interface{})
+		if !ok || deleted(object) {
+			continue
+		}
+
+		kind, _ := object["type"].(string)
+		address, _ := object["address"].(string)
+		violations = append(violations, p.checkCel(Terraform, repo, kind, address, object)...)
+	}
+	return sorted(violations), nil
+}
+
+func (p *Policies) check(target, repo, kind, resource string, object map[string]interface{}) ([]*Violation, error) {
+	violations := make([]*Violation, 0)
+	if prepared, ok := p.rego[target]; ok {
+		found, err := prepared.eval(repo, resource, object)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+
+	return append(violations, p.checkCel(target, repo, kind, resource, object)...), nil
+}
+
+func (p *Policies) checkCel(target, repo, kind, resource string, object map[string]interface{}) []*Violation {
+	violations := make([]*Violation, 0)
+	for _, pol := range p.cel {
+		if pol.Target != target || !pol.matches(kind) {
+			continue
+		}
+
+		if msg, ok := pol.eval(repo, object); !ok {
+			violations = append(violations, &Violation{Policy: pol.Name, Repo: repo, Resource: resource, Message: msg})
+		}
+	}
+	return violations
+}
+
+// resources being destroyed can't break a policy
+func deleted(change map[string]interface{}) bool {
+	detail, _ := change["change"].(map[string]interface{})
+	actions, _ := detail["actions"].([]interface{})
+	return len(actions) == 1 && actions[0] == "delete"
+}
+
+// roundTrip converts typed values into the plain maps and slices policies are evaluated against
+func roundTrip(from interface{}, to interface{}) error {
+	raw, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, to)
+}
+
+func sorted(violations []*Violation) []*Violation {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Resource != violations[j].Resource {
+			return violations[i].Resource < violations[j].Resource
+		}
+		return violations[i].Policy < violations[j].Policy
+	})
+	return violations
+}
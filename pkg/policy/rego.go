@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// regoTarget evaluates the deny rules of every package under plural.kubernetes or plural.terraform, eg:
+//
+//	package plural.kubernetes.limits
+//
+//	deny[msg] {
+//		container := input.spec.template.spec.containers[_]
+//		not container.resources.limits
+//		msg := sprintf("container %s has no resource limits", [container.name])
+//	}
+//
+// A deny rule can also produce objects with a msg, and a resource for terraform policies as they're evaluated
+// against the whole plan.
+type regoTarget struct {
+	target string
+	query  rego.PreparedEvalQuery
+}
+
+func prepareRego(target string, modules map[string]string) (*regoTarget, error) {
+	opts := []func(*rego.Rego){rego.Query(fmt.Sprintf("data.plural.%s", target))}
+	for path, contents := range modules {
+		opts = append(opts, rego.Module(path, contents))
+	}
+
+	query, err := rego.New(opts...).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("invalid rego policies: %w", err)
+	}
+	return &regoTarget{target: target, query: query}, nil
+}
+
+func (r *regoTarget) eval(repo, resource string, input interface{}) ([]*Violation, error) {
+	results, err := r.query.Eval(context.Background(), rego.EvalInput(input))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]*Violation, 0)
+	for _, result := range results {
+		for _, expr := range result.Expressions {
+			if pkg, ok := expr.Value.(map[string]interface{}); ok {
+				violations = append(violations, denials(pkg, []string{r.target}, repo, resource)...)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// denials collects the deny rules of pkg and the packages nested beneath it, each named after its package
+func denials(pkg map[string]interface{}, path []string, repo, resource string) []*Violation {
+	keys := make([]string, 0, len(pkg))
+	for key := range pkg {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	violations := make([]*Violation, 0)
+	for _, key := range keys {
+		switch val := pkg[key].(type) {
+		case []interface{}:
+			if key != "deny" {
+				continue
+			}
+			for _, denial := range val {
+				violations = append(violations, denialViolation(denial, strings.Join(path, "."), repo, resource))
+			}
+		case map[string]interface{}:
+			violations = append(violations, denials(val, append(path, key), repo, resource)...)
+		}
+	}
+	return violations
+}
+
+func denialViolation(denial interface{}, policy, repo, resource string) *Violation {
+	violation := &Violation{Policy: policy, Repo: repo, Resource: resource}
+	switch d := denial.(type) {
+	case string:
+		violation.Message = d
+	case map[string]interface{}:
+		violation.Message = fmt.Sprint(d["msg"])
+		if res, ok := d["resource"].(string); ok {
+			violation.Resource = res
+		}
+	default:
+		violation.Message = fmt.Sprint(d)
+	}
+	return violation
+}
@@ -3,15 +3,19 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
 	"os/exec"
 
 	"github.com/AlecAivazis/survey/v2"
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smTypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/template"
@@ -212,7 +216,13 @@ func (aws *AWSProvider) Context() map[string]interface{} {
 }
 
 func (aws *AWSProvider) Preflights() []*Preflight {
-	return nil
+	return []*Preflight{
+		{Name: "Caller Identity", Callback: aws.validateIdentity},
+		{Name: "Enabled Region", Callback: aws.validateRegion},
+		{Name: "IAM Permissions", Callback: aws.validatePermissions},
+		{Name: "Service Quotas", Callback: aws.validateQuotas},
+		{Name: "State Bucket", Callback: aws.validateBucket},
+	}
 }
 
 func (aws *AWSProvider) Flush() error {
@@ -253,6 +263,68 @@ func (prov *AWSProvider) Decommision(node *v1.Node) error {
 	return errors.ErrorWrap(err, "failed to terminate instance")
 }
 
+func (prov *AWSProvider) loadConfig() (awsSdk.Config, error) {
+	cfg, err := awsConfig.LoadDefaultConfig(*prov.goContext)
+	if err != nil {
+		return cfg, errors.ErrorWrap(err, "Failed to establish aws session")
+	}
+
+	cfg.Region = prov.Region()
+	return cfg, nil
+}
+
+func (prov *AWSProvider) secretsClient() (*secretsmanager.Client, error) {
+	cfg, err := prov.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return secretsmanager.NewFromConfig(cfg), nil
+}
+
+func (prov *AWSProvider) StoreSecret(name string, value []byte) error {
+	client, err := prov.secretsClient()
+	if err != nil {
+		return err
+	}
+
+	secret := string(value)
+	_, err = client.PutSecretValue(*prov.goContext, &secretsmanager.PutSecretValueInput{
+		SecretId:     &name,
+		SecretString: &secret,
+	})
+
+	var notFound *smTypes.ResourceNotFoundException
+	if goerrors.As(err, &notFound) {
+		_, err = client.CreateSecret(*prov.goContext, &secretsmanager.CreateSecretInput{
+			Name:         &name,
+			SecretString: &secret,
+		})
+	}
+
+	return errors.ErrorWrap(err, "failed to store secret in aws secrets manager")
+}
+
+func (prov *AWSProvider) FetchSecret(name string) ([]byte, error) {
+	client, err := prov.secretsClient()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.GetSecretValue(*prov.goContext, &secretsmanager.GetSecretValueInput{SecretId: &name})
+	if err != nil {
+		return nil, errors.ErrorWrap(err, "failed to read secret from aws secrets manager")
+	}
+
+	if res.SecretString != nil {
+		return []byte(*res.SecretString), nil
+	}
+	if res.SecretBinary != nil {
+		return res.SecretBinary, nil
+	}
+	return nil, fmt.Errorf("aws secret %s has no value", name)
+}
+
 func GetAwsAccount() (string, error) {
 	cmd := exec.Command("aws", "sts", "get-caller-identity")
 	out, err := cmd.Output()
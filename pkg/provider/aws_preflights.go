@@ -0,0 +1,265 @@
+package provider
+
+import (
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// the iam actions exercised by the aws bootstrap scaffolds
+var awsRequiredActions = []string{
+	"eks:CreateCluster",
+	"eks:DescribeCluster",
+	"eks:CreateNodegroup",
+	"ec2:CreateVpc",
+	"ec2:CreateSubnet",
+	"ec2:CreateNatGateway",
+	"ec2:AllocateAddress",
+	"ec2:CreateSecurityGroup",
+	"ec2:RunInstances",
+	"iam:CreateRole",
+	"iam:AttachRolePolicy",
+	"iam:PassRole",
+	"iam:CreateOpenIDConnectProvider",
+	"s3:CreateBucket",
+	"s3:PutObject",
+	"s3:GetObject",
+}
+
+type awsQuota struct {
+	name    string
+	service string
+	code    string
+	needed  int
+	usage   func(prov *AWSProvider, cfg awsSdk.Config) (int, error)
+}
+
+var awsQuotas = []awsQuota{
+	{name: "VPCs per region", service: "vpc", code: "L-F678F1CE", needed: 1, usage: vpcUsage},
+	{name: "Elastic IPs", service: "ec2", code: "L-0263D0A3", needed: 1, usage: eipUsage},
+	{name: "EKS clusters", service: "eks", code: "L-1194D53C", needed: 1, usage: eksUsage},
+}
+
+func (aws *AWSProvider) callerIdentity() (*sts.GetCallerIdentityOutput, error) {
+	cfg, err := aws.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return sts.NewFromConfig(cfg).GetCallerIdentity(*aws.goContext, &sts.GetCallerIdentityInput{})
+}
+
+func (aws *AWSProvider) validateIdentity() error {
+	identity, err := aws.callerIdentity()
+	if err != nil {
+		return fmt.Errorf("could not determine your aws identity, is your aws cli configured? %s", err)
+	}
+
+	if account := awsSdk.ToString(identity.Account); aws.Project() != "" && account != aws.Project() {
+		return fmt.Errorf("your credentials are for aws account %s, but this workspace is configured for account %s", account, aws.Project())
+	}
+
+	return nil
+}
+
+func (aws *AWSProvider) validateRegion() error {
+	cfg, err := aws.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	regions, err := ec2.NewFromConfig(cfg).DescribeRegions(*aws.goContext, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return err
+	}
+
+	for _, region := range regions.Regions {
+		if awsSdk.ToString(region.RegionName) == aws.Region() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("region %s is not enabled for your account, enable it in the aws console or choose another region", aws.Region())
+}
+
+func (aws *AWSProvider) validatePermissions() error {
+	identity, err := aws.callerIdentity()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := aws.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := iam.NewFromConfig(cfg)
+	arn, err := aws.principalArn(client, awsSdk.ToString(identity.Arn))
+	if err != nil {
+		return err
+	}
+
+	// the root user can't be simulated, and can do everything anyways
+	if strings.HasSuffix(arn, ":root") {
+		return nil
+	}
+
+	res, err := client.SimulatePrincipalPolicy(*aws.goContext, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: &arn,
+		ActionNames:     awsRequiredActions,
+	})
+	if err != nil {
+		return fmt.Errorf("could not simulate iam policies for %s (this requires iam:SimulatePrincipalPolicy): %s", arn, err)
+	}
+
+	denied := make([]string, 0)
+	for _, result := range res.EvaluationResults {
+		if result.EvalDecision != iamTypes.PolicyEvaluationDecisionTypeAllowed {
+			denied = append(denied, awsSdk.ToString(result.EvalActionName))
+		}
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("%s is missing required iam permissions: %s", arn, strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+// principalArn maps an sts assumed-role arn back to the iam role it came from, which is what iam simulation expects
+func (aws *AWSProvider) principalArn(client *iam.Client, arn string) (string, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return arn, nil
+	}
+
+	role := strings.Split(strings.TrimPrefix(parts[5], "assumed-role/"), "/")[0]
+	res, err := client.GetRole(*aws.goContext, &iam.GetRoleInput{RoleName: &role})
+	if err != nil {
+		return "", fmt.Errorf("could not look up iam role %s: %s", role, err)
+	}
+
+	return awsSdk.ToString(res.Role.Arn), nil
+}
+
+func (aws *AWSProvider) validateQuotas() error {
+	cfg, err := aws.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client := servicequotas.NewFromConfig(cfg)
+	exhausted := make([]string, 0)
+	for _, quota := range awsQuotas {
+		limit, err := aws.quotaValue(client, quota)
+		if err != nil {
+			return fmt.Errorf("could not fetch the %s quota: %s", quota.name, err)
+		}
+
+		used, err := quota.usage(aws, cfg)
+		if err != nil {
+			return fmt.Errorf("could not determine usage for %s: %s", quota.name, err)
+		}
+
+		if used+quota.needed > limit {
+			exhausted = append(exhausted, fmt.Sprintf("%s (%d of %d used, %d needed)", quota.name, used, limit, quota.needed))
+		}
+	}
+
+	if len(exhausted) > 0 {
+		return fmt.Errorf("not enough quota in %s, request an increase for: %s", aws.Region(), strings.Join(exhausted, ", "))
+	}
+
+	return nil
+}
+
+func (aws *AWSProvider) quotaValue(client *servicequotas.Client, quota awsQuota) (int, error) {
+	res, err := client.GetServiceQuota(*aws.goContext, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: &quota.service,
+		QuotaCode:   &quota.code,
+	})
+	if err == nil {
+		return int(awsSdk.ToFloat64(res.Quota.Value)), nil
+	}
+
+	// quotas that were never adjusted only exist as aws defaults
+	def, err := client.GetAWSDefaultServiceQuota(*aws.goContext, &servicequotas.GetAWSDefaultServiceQuotaInput{
+		ServiceCode: &quota.service,
+		QuotaCode:   &quota.code,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(awsSdk.ToFloat64(def.Quota.Value)), nil
+}
+
+func vpcUsage(prov *AWSProvider, cfg awsSdk.Config) (int, error) {
+	res, err := ec2.NewFromConfig(cfg).DescribeVpcs(*prov.goContext, &ec2.DescribeVpcsInput{})
+	if err != nil {
+		return 0, err
+	}
+	return len(res.Vpcs), nil
+}
+
+func eipUsage(prov *AWSProvider, cfg awsSdk.Config) (int, error) {
+	res, err := ec2.NewFromConfig(cfg).DescribeAddresses(*prov.goContext, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return 0, err
+	}
+	return len(res.Addresses), nil
+}
+
+func eksUsage(prov *AWSProvider, cfg awsSdk.Config) (int, error) {
+	paginator := eks.NewListClustersPaginator(eks.NewFromConfig(cfg), &eks.ListClustersInput{})
+	count := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(*prov.goContext)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, cluster := range page.Clusters {
+			// an existing cluster of the same name is simply reused, so doesn't count against the quota
+			if cluster != prov.Cluster() {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (aws *AWSProvider) validateBucket() error {
+	bucket := aws.Bucket()
+	_, err := aws.storageClient.HeadBucket(*aws.goContext, &s3.HeadBucketInput{Bucket: &bucket})
+	if err == nil {
+		return nil
+	}
+
+	var respErr *awshttp.ResponseError
+	if !goerrors.As(err, &respErr) {
+		return err
+	}
+
+	switch respErr.HTTPStatusCode() {
+	case http.StatusNotFound:
+		return nil
+	case http.StatusForbidden:
+		return fmt.Errorf("the state bucket %s is owned by another aws account, choose a different bucket name in workspace.yaml", bucket)
+	case http.StatusMovedPermanently:
+		return fmt.Errorf("the state bucket %s already exists outside of %s, choose a different bucket name in workspace.yaml", bucket, aws.Region())
+	default:
+		return err
+	}
+}
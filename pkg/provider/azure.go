@@ -9,6 +9,8 @@ import (
 	"os/exec"
 
 	"github.com/AlecAivazis/survey/v2"
+	kvauth "github.com/Azure/azure-sdk-for-go/services/keyvault/auth"
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2019-06-01/storage"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/go-autorest/autorest"
@@ -202,7 +204,11 @@ func (az *AzureProvider) Context() map[string]interface{} {
 }
 
 func (az *AzureProvider) Preflights() []*Preflight {
-	return nil
+	return []*Preflight{
+		{Name: "Resource Providers", Callback: az.validateProviders},
+		{Name: "Role Assignments", Callback: az.validateRoles},
+		{Name: "Regional vCPU Quota", Callback: az.validateQuota},
+	}
 }
 
 func (azure *AzureProvider) Flush() error {
@@ -232,6 +238,49 @@ func (az *AzureProvider) Authorizer() (autorest.Authorizer, error) {
 	return auth.NewAuthorizerFromCLI()
 }
 
+func (az *AzureProvider) keyVaultClient() (client keyvault.BaseClient, vaultUrl string, err error) {
+	vault, ok := az.ctx["KeyVault"]
+	if !ok {
+		err = fmt.Errorf("No key vault configured, add a KeyVault entry to the context in your workspace.yaml")
+		return
+	}
+
+	client = keyvault.New()
+	if os.Getenv("ARM_USE_MSI") != "" {
+		client.Authorizer, err = kvauth.NewAuthorizerFromEnvironment()
+	} else {
+		client.Authorizer, err = kvauth.NewAuthorizerFromCLI()
+	}
+	vaultUrl = fmt.Sprintf("https://%s.vault.azure.net", vault)
+	return
+}
+
+func (az *AzureProvider) StoreSecret(name string, value []byte) error {
+	client, vaultUrl, err := az.keyVaultClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SetSecret(context.Background(), vaultUrl, name, keyvault.SecretSetParameters{
+		Value: to.StringPtr(string(value)),
+	})
+	return errors.ErrorWrap(err, "failed to store secret in key vault")
+}
+
+func (az *AzureProvider) FetchSecret(name string) ([]byte, error) {
+	client, vaultUrl, err := az.keyVaultClient()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := client.GetSecret(context.Background(), vaultUrl, name, "")
+	if err != nil {
+		return nil, errors.ErrorWrap(err, "failed to read secret from key vault")
+	}
+
+	return []byte(*bundle.Value), nil
+}
+
 func (az *AzureProvider) getStorageAccountsClient() storage.AccountsClient {
 	storageAccountsClient := storage.NewAccountsClient(utils.ToString(az.ctx["SubscriptionId"]))
 	auth, _ := az.Authorizer()
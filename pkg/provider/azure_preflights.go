@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2020-06-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/azure/azure-sdk-for-go/services/compute/mgmt/2021-07-01/compute"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+// minimum regional vcpu headroom for the default aks node pools
+const azureRequiredVCPUs = 6
+
+var azureResourceProviders = []string{
+	"Microsoft.ContainerService",
+	"Microsoft.Storage",
+	"Microsoft.Network",
+	"Microsoft.Compute",
+}
+
+// built-in role definition ids, which are identical across all subscriptions
+const (
+	azureOwnerRole           = "8e3af657-a8ff-443c-a75c-2fe8c4bcb635"
+	azureContributorRole     = "b24988ac-6180-42a0-ab88-20f7382dd24c"
+	azureUserAccessAdminRole = "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9"
+)
+
+func (az *AzureProvider) subscriptionId() string {
+	return utils.ToString(az.ctx["SubscriptionId"])
+}
+
+func (az *AzureProvider) validateProviders() error {
+	client := resources.NewProvidersClient(az.subscriptionId())
+	authorizer, err := az.Authorizer()
+	if err != nil {
+		return err
+	}
+	client.Authorizer = authorizer
+
+	unregistered := make([]string, 0)
+	for _, namespace := range azureResourceProviders {
+		prov, err := client.Get(context.Background(), namespace, "")
+		if err != nil {
+			return fmt.Errorf("could not fetch resource provider %s: %s", namespace, err)
+		}
+
+		if prov.RegistrationState == nil || *prov.RegistrationState != "Registered" {
+			unregistered = append(unregistered, namespace)
+		}
+	}
+
+	if len(unregistered) > 0 {
+		return fmt.Errorf("Resource providers %s aren't registered in your subscription, run `az provider register --namespace <name>` for each", strings.Join(unregistered, ", "))
+	}
+
+	return nil
+}
+
+func (az *AzureProvider) validateRoles() error {
+	authorizer, err := az.Authorizer()
+	if err != nil {
+		return err
+	}
+
+	principal, err := azurePrincipalId(authorizer)
+	if err != nil {
+		return err
+	}
+
+	client := authorization.NewRoleAssignmentsClient(az.subscriptionId())
+	client.Authorizer = authorizer
+	iter, err := client.ListComplete(context.Background(), fmt.Sprintf("assignedTo('%s')", principal))
+	if err != nil {
+		return fmt.Errorf("could not list your role assignments: %s", err)
+	}
+
+	roles := map[string]bool{}
+	subscriptionScope := fmt.Sprintf("/subscriptions/%s", az.subscriptionId())
+	for ; iter.NotDone(); err = iter.NextWithContext(context.Background()) {
+		if err != nil {
+			return err
+		}
+
+		props := iter.Value().Properties
+		if props == nil || props.RoleDefinitionID == nil || props.Scope == nil {
+			continue
+		}
+
+		// only assignments covering the whole subscription are sufficient
+		if strings.EqualFold(*props.Scope, subscriptionScope) || *props.Scope == "/" {
+			parts := strings.Split(*props.RoleDefinitionID, "/")
+			roles[parts[len(parts)-1]] = true
+		}
+	}
+
+	if roles[azureOwnerRole] || (roles[azureContributorRole] && roles[azureUserAccessAdminRole]) {
+		return nil
+	}
+
+	return fmt.Errorf("You need either the Owner role, or both Contributor and User Access Administrator, on subscription %s", az.subscriptionId())
+}
+
+func (az *AzureProvider) validateQuota() error {
+	client := compute.NewUsageClient(az.subscriptionId())
+	authorizer, err := az.Authorizer()
+	if err != nil {
+		return err
+	}
+	client.Authorizer = authorizer
+
+	iter, err := client.ListComplete(context.Background(), az.Region())
+	if err != nil {
+		return fmt.Errorf("could not fetch compute usage for %s: %s", az.Region(), err)
+	}
+
+	for ; iter.NotDone(); err = iter.NextWithContext(context.Background()) {
+		if err != nil {
+			return err
+		}
+
+		usage := iter.Value()
+		if usage.Name == nil || usage.Name.Value == nil || *usage.Name.Value != "cores" {
+			continue
+		}
+
+		// both are optional in the api's response
+		if usage.Limit == nil || usage.CurrentValue == nil {
+			continue
+		}
+
+		available := *usage.Limit - int64(*usage.CurrentValue)
+		if available < azureRequiredVCPUs {
+			return fmt.Errorf("Only %d regional vCPUs are available in %s, but at least %d are needed, request a quota increase in the azure portal", available, az.Region(), azureRequiredVCPUs)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("could not find regional vCPU quota for %s", az.Region())
+}
+
+// azurePrincipalId reads the object id of the authenticated user or service principal from its access token
+func azurePrincipalId(authorizer autorest.Authorizer) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://management.azure.com/", nil)
+	if err != nil {
+		return "", err
+	}
+
+	req, err = autorest.Prepare(req, authorizer.WithAuthorization())
+	if err != nil {
+		return "", fmt.Errorf("could not authenticate to azure, are you logged in with `az login`? %s", err)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("unexpected azure access token format")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	var claims struct {
+		Oid string `json:"oid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", err
+	}
+
+	return claims.Oid, nil
+}
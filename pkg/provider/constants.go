@@ -6,4 +6,15 @@ const (
 	AZURE   = "azure"
 	EQUINIX = "equinix"
 	KIND    = "kind"
+	GENERIC = "generic"
 )
+
+// TerraformProviders lists the terraform providers managing each cloud's resources, anything else
+// in a workspace's state (eg kubernetes or helm resources) isn't tied to a particular cloud
+var TerraformProviders = map[string][]string{
+	GCP:     {"hashicorp/google", "hashicorp/google-beta"},
+	AWS:     {"hashicorp/aws"},
+	AZURE:   {"hashicorp/azurerm", "hashicorp/azuread"},
+	EQUINIX: {"equinix/metal", "equinix/equinix"},
+	KIND:    {"tehcyx/kind"},
+}
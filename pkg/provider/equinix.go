@@ -236,7 +236,11 @@ func (equinix *EQUINIXProvider) Context() map[string]interface{} {
 }
 
 func (equinix *EQUINIXProvider) Preflights() []*Preflight {
-	return nil
+	return []*Preflight{
+		{Name: "Project Exists", Callback: equinix.validateProject},
+		{Name: "API Token Scope", Callback: equinix.validateToken},
+		{Name: "Metro Capacity", Callback: equinix.validateCapacity},
+	}
 }
 
 func (equinix *EQUINIXProvider) Flush() error {
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"fmt"
+
+	metal "github.com/packethost/packngo"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const (
+	equinixDefaultPlan = "c3.small.x86"
+	equinixNodeCount   = 3
+)
+
+func (equinix *EQUINIXProvider) metalClient() *metal.Client {
+	return getMetalClient(utils.ToString(equinix.ctx["ApiToken"]))
+}
+
+func (equinix *EQUINIXProvider) plan() string {
+	if plan, ok := equinix.ctx["Plan"]; ok {
+		return utils.ToString(plan)
+	}
+	return equinixDefaultPlan
+}
+
+func (equinix *EQUINIXProvider) validateProject() error {
+	if _, _, err := equinix.metalClient().Projects.Get(equinix.Project(), nil); err != nil {
+		return fmt.Errorf("Could not find equinix metal project %s, does your api token have access to it? %s", equinix.Project(), err)
+	}
+
+	return nil
+}
+
+func (equinix *EQUINIXProvider) validateToken() error {
+	client := equinix.metalClient()
+	token := utils.ToString(equinix.ctx["ApiToken"])
+
+	// the token is either a user token or a project token, and we need to find it to check its scope
+	keys, _, err := client.APIKeys.UserList(nil)
+	if err != nil {
+		keys, _, err = client.APIKeys.ProjectList(equinix.Project(), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("could not list api tokens: %s", err)
+	}
+
+	for _, key := range keys {
+		if key.Token != token {
+			continue
+		}
+
+		if key.ReadOnly {
+			return fmt.Errorf("Your equinix metal api token is read-only, create a read/write token to provision a cluster")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("could not find your api token in your user or project tokens")
+}
+
+func (equinix *EQUINIXProvider) validateCapacity() error {
+	plan := equinix.plan()
+	res, _, err := equinix.metalClient().CapacityService.CheckMetros(&metal.CapacityInput{
+		Servers: []metal.ServerInfo{{Metro: equinix.Region(), Plan: plan, Quantity: equinixNodeCount}},
+	})
+	if err != nil {
+		return fmt.Errorf("could not check capacity in metro %s: %s", equinix.Region(), err)
+	}
+
+	for _, server := range res.Servers {
+		if !server.Available {
+			return fmt.Errorf("Metro %s doesn't have capacity for %d %s servers, choose another metro or plan", equinix.Region(), equinixNodeCount, plan)
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	compute "cloud.google.com/go/compute/apiv1"
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/storage"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pluralsh/plural/pkg/config"
@@ -19,6 +20,9 @@ import (
 	serviceusagepb "google.golang.org/genproto/googleapis/api/serviceusage/v1"
 	resourcemanager "cloud.google.com/go/resourcemanager/apiv3"
 	resourcemanagerpb "google.golang.org/genproto/googleapis/cloud/resourcemanager/v3"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -308,4 +312,56 @@ func (gcp *GCPProvider) getProject() (*resourcemanagerpb.Project, error) {
 	}
 	defer c.Close()
 	return c.GetProject(ctx, &resourcemanagerpb.GetProjectRequest{Name: fmt.Sprintf("projects/%s", gcp.Project())})
-}
\ No newline at end of file
+}
+
+func (gcp *GCPProvider) StoreSecret(name string, value []byte) error {
+	ctx := context.Background()
+	c, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return errors.ErrorWrap(err, "failed to initialize secret manager client")
+	}
+	defer c.Close()
+
+	parent := fmt.Sprintf("projects/%s", gcp.Project())
+	secretName := fmt.Sprintf("%s/secrets/%s", parent, name)
+	if _, err := c.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: secretName}); status.Code(err) == codes.NotFound {
+		_, err = c.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+			Parent:   parent,
+			SecretId: name,
+			Secret: &secretmanagerpb.Secret{
+				Replication: &secretmanagerpb.Replication{
+					Replication: &secretmanagerpb.Replication_Automatic_{Automatic: &secretmanagerpb.Replication_Automatic{}},
+				},
+			},
+		})
+		if err != nil {
+			return errors.ErrorWrap(err, "failed to create secret")
+		}
+	} else if err != nil {
+		return errors.ErrorWrap(err, "failed to read secret")
+	}
+
+	_, err = c.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  secretName,
+		Payload: &secretmanagerpb.SecretPayload{Data: value},
+	})
+	return errors.ErrorWrap(err, "failed to add secret version")
+}
+
+func (gcp *GCPProvider) FetchSecret(name string) ([]byte, error) {
+	ctx := context.Background()
+	c, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, errors.ErrorWrap(err, "failed to initialize secret manager client")
+	}
+	defer c.Close()
+
+	res, err := c.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", gcp.Project(), name),
+	})
+	if err != nil {
+		return nil, errors.ErrorWrap(err, "failed to access secret")
+	}
+
+	return res.Payload.Data, nil
+}
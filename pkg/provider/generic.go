@@ -0,0 +1,318 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/template"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	genericS3Backend    = "s3"
+	genericLocalBackend = "local"
+)
+
+// the generic provider manages no infrastructure, so its scaffold is just a backend and a kubernetes provider
+// pointed at the configured kubeconfig context, rather than one fetched from the api
+const genericScaffold = `terraform {
+{{- if eq .Values.Backend "s3" }}
+  backend "s3" {
+    bucket = "{{ .Values.Bucket }}"
+    key = "{{ .Values.__CLUSTER__ }}/{{ .Values.Prefix }}/terraform.tfstate"
+    region = "{{ .Values.Region }}"
+    endpoint = "{{ .Values.Endpoint }}"
+    force_path_style = true
+    skip_credentials_validation = true
+    skip_metadata_api_check = true
+    skip_region_validation = true
+  }
+{{- else }}
+  backend "local" {
+    path = "../../{{ .Values.Bucket }}/{{ .Values.Prefix }}/terraform.tfstate"
+  }
+{{- end }}
+
+  required_providers {
+    kubernetes = {
+      source  = "hashicorp/kubernetes"
+      version = "~> 2.0"
+    }
+  }
+}
+
+provider "kubernetes" {
+  config_paths = {{ .Values.KubeConfigs | toJson }}
+  config_context = "{{ .Values.KubeContext }}"
+}
+`
+
+type GenericProvider struct {
+	Clust  string `survey:"cluster"`
+	Proj   string
+	bucket string
+	Reg    string
+	ctx    map[string]interface{}
+	writer manifest.Writer
+}
+
+func mkGeneric(conf config.Config) (provider *GenericProvider, err error) {
+	contexts, current, err := kubeContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("No contexts found in your kubeconfig, the generic provider needs access to an existing cluster")
+	}
+
+	var resp struct {
+		Cluster     string
+		KubeContext string
+		Backend     string
+		Endpoint    string
+		Region      string
+	}
+	questions := []*survey.Question{
+		{
+			Name:     "cluster",
+			Prompt:   &survey.Input{Message: "Enter the name of your cluster:"},
+			Validate: validCluster,
+		},
+		{
+			Name:     "kubeContext",
+			Prompt:   &survey.Select{Message: "Which kubeconfig context points at your cluster?", Options: contexts, Default: current},
+			Validate: survey.Required,
+		},
+		{
+			Name:   "backend",
+			Prompt: &survey.Select{Message: "Where should terraform state be stored?", Options: []string{genericS3Backend, genericLocalBackend}},
+		},
+	}
+	if err = survey.Ask(questions, &resp); err != nil {
+		return
+	}
+
+	resp.Region = "us-east-1"
+	if resp.Backend == genericS3Backend {
+		s3Questions := []*survey.Question{
+			{
+				Name:     "endpoint",
+				Prompt:   &survey.Input{Message: "Enter the endpoint of your S3-compatible store (eg https://minio.example.com):"},
+				Validate: survey.Required,
+			},
+			{
+				Name:   "region",
+				Prompt: &survey.Input{Message: "What region is your bucket in?", Default: resp.Region},
+			},
+		}
+		if err = survey.Ask(s3Questions, &resp); err != nil {
+			return
+		}
+	}
+
+	provider = &GenericProvider{
+		Clust: resp.Cluster,
+		Reg:   resp.Region,
+		ctx: map[string]interface{}{
+			"KubeContext": resp.KubeContext,
+			"Backend":     resp.Backend,
+			"Endpoint":    resp.Endpoint,
+		},
+	}
+
+	projectManifest := manifest.ProjectManifest{
+		Cluster:  provider.Cluster(),
+		Project:  provider.Project(),
+		Provider: GENERIC,
+		Region:   provider.Region(),
+		Context:  provider.Context(),
+		Owner:    &manifest.Owner{Email: conf.Email, Endpoint: conf.Endpoint},
+	}
+
+	provider.writer = projectManifest.Configure()
+	provider.bucket = projectManifest.Bucket
+	return
+}
+
+func genericFromManifest(man *manifest.ProjectManifest) (*GenericProvider, error) {
+	return &GenericProvider{man.Cluster, man.Project, man.Bucket, man.Region, man.Context, nil}, nil
+}
+
+func (gen *GenericProvider) CreateBackend(prefix string, ctx map[string]interface{}) (string, error) {
+	ctx["Region"] = gen.Region()
+	ctx["Bucket"] = gen.Bucket()
+	ctx["Prefix"] = prefix
+	ctx["ClusterCreated"] = false
+	ctx["__CLUSTER__"] = gen.Cluster()
+	ctx["Cluster"] = fmt.Sprintf(`"%s"`, gen.Cluster())
+	ctx["KubeContext"] = gen.kubeContext()
+	ctx["Backend"] = gen.backend()
+	ctx["Endpoint"] = gen.Context()["Endpoint"]
+	ctx["KubeConfigs"] = gen.kubeConfigs()
+
+	if gen.backend() == genericLocalBackend {
+		// the state lives in the workspace, encrypted like everything else in it
+		root, err := git.Root()
+		if err != nil {
+			return "", err
+		}
+
+		stateDir := pathing.SanitizeFilepath(filepath.Join(root, gen.Bucket()))
+		if err := utils.WriteFile(pathing.SanitizeFilepath(filepath.Join(stateDir, ".gitignore")), []byte("!/**")); err != nil {
+			return "", err
+		}
+		if err := utils.WriteFile(pathing.SanitizeFilepath(filepath.Join(stateDir, ".gitattributes")), []byte("/** filter=plural-crypt diff=plural-crypt\n.gitattributes !filter !diff")); err != nil {
+			return "", err
+		}
+	}
+
+	return template.RenderString(genericScaffold, ctx)
+}
+
+// KubeConfig switches the current context of ~/.kube/config to the one chosen for this workspace
+func (gen *GenericProvider) KubeConfig() error {
+	if utils.InKubernetes() {
+		return nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	conf, err := rules.Load()
+	if err != nil {
+		return err
+	}
+
+	kubeContext := gen.kubeContext()
+	if _, ok := conf.Contexts[kubeContext]; !ok {
+		return fmt.Errorf("Could not find context %s in your kubeconfig", kubeContext)
+	}
+
+	conf.CurrentContext = kubeContext
+	return clientcmd.ModifyConfig(rules, *conf, true)
+}
+
+func (gen *GenericProvider) Name() string {
+	return GENERIC
+}
+
+func (gen *GenericProvider) Cluster() string {
+	return gen.Clust
+}
+
+func (gen *GenericProvider) Project() string {
+	return gen.Proj
+}
+
+func (gen *GenericProvider) Bucket() string {
+	return gen.bucket
+}
+
+func (gen *GenericProvider) Region() string {
+	return gen.Reg
+}
+
+func (gen *GenericProvider) Context() map[string]interface{} {
+	return gen.ctx
+}
+
+// Decommision only cordons and drains the node, since plural has no way of removing the underlying machine
+func (gen *GenericProvider) Decommision(node *v1.Node) error {
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return err
+	}
+
+	if err := kube.Cordon(node); err != nil {
+		return err
+	}
+
+	// plural ops terminate has already refused to drain away unmanaged pods unless --force was passed
+	if err := kube.Drain(node, utils.DefaultDrainTimeout, true); err != nil {
+		return err
+	}
+
+	utils.Warn("Node %s has been drained, you'll need to remove the machine backing it yourself\n", node.Name)
+	return nil
+}
+
+func (gen *GenericProvider) Preflights() []*Preflight {
+	return []*Preflight{
+		{Name: "Cluster Reachable", Callback: gen.validateCluster},
+	}
+}
+
+func (gen *GenericProvider) validateCluster() error {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: gen.kubeContext()}
+	conf, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("Could not reach the cluster behind context %s: %w", gen.kubeContext(), err)
+	}
+	return nil
+}
+
+func (gen *GenericProvider) Flush() error {
+	if gen.writer == nil {
+		return nil
+	}
+
+	return gen.writer()
+}
+
+func (gen *GenericProvider) kubeContext() string {
+	kubeContext, _ := gen.Context()["KubeContext"].(string)
+	return kubeContext
+}
+
+// kubeConfigs are the kubeconfig files terraform reads, the workspace's KubeConfig context value if it's set, otherwise
+// those in $KUBECONFIG like kubectl would
+func (gen *GenericProvider) kubeConfigs() []string {
+	if path, ok := gen.Context()["KubeConfig"].(string); ok && path != "" {
+		return []string{path}
+	}
+
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 {
+		return paths
+	}
+	return []string{"~/.kube/config"}
+}
+
+func (gen *GenericProvider) backend() string {
+	if backend, ok := gen.Context()["Backend"].(string); ok && backend != "" {
+		return backend
+	}
+	return genericLocalBackend
+}
+
+func kubeContexts() ([]string, string, error) {
+	conf, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	contexts := make([]string, 0, len(conf.Contexts))
+	for name := range conf.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, conf.CurrentContext, nil
+}
@@ -14,6 +14,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 )
 
+const (
+	kindSecretNamespace = "kube-system"
+	kindSecretKey       = "value"
+)
+
 type KINDProvider struct {
 	Clust  string `survey:"cluster"`
 	Proj   string
@@ -130,6 +135,29 @@ func (prov *KINDProvider) Preflights() []*Preflight {
 	return nil
 }
 
+func (kind *KINDProvider) StoreSecret(name string, value []byte) error {
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return err
+	}
+
+	return kube.SecretUpsert(kindSecretNamespace, name, map[string][]byte{kindSecretKey: value})
+}
+
+func (kind *KINDProvider) FetchSecret(name string) ([]byte, error) {
+	kube, err := utils.Kubernetes()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := kube.Secret(kindSecretNamespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Data[kindSecretKey], nil
+}
+
 func (kind *KINDProvider) Flush() error {
 	if kind.writer == nil {
 		return nil
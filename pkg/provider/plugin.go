@@ -0,0 +1,379 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Provider plugins are executables named plural-provider-<name> in ~/.plural/plugins.  They're started
+// on first use and speak JSON-RPC 1.0 over stdin/stdout, serving the methods below on a "Provider" service.
+// Every call carries the workspace's PluginManifest, so plugins can remain stateless, and a plugin
+// should exit once its stdin is closed.
+const (
+	pluginPrefix = "plural-provider-"
+
+	pluginKubeConfig    = "Provider.KubeConfig"
+	pluginCreateBackend = "Provider.CreateBackend"
+	pluginDecommision   = "Provider.Decommision"
+	pluginPreflights    = "Provider.Preflights"
+	pluginPreflight     = "Provider.Preflight"
+)
+
+type PluginManifest struct {
+	Cluster string
+	Project string
+	Region  string
+	Bucket  string
+	Context map[string]interface{}
+}
+
+type PluginArgs struct {
+	Manifest *PluginManifest
+}
+
+type PluginBackendArgs struct {
+	Manifest *PluginManifest
+	Prefix   string
+	Context  map[string]interface{}
+}
+
+type PluginBackendReply struct {
+	Backend string
+}
+
+type PluginNodeArgs struct {
+	Manifest *PluginManifest
+	Node     *v1.Node
+}
+
+type PluginPreflightsReply struct {
+	Names []string
+}
+
+type PluginPreflightArgs struct {
+	Manifest *PluginManifest
+	Name     string
+}
+
+type PluginReply struct{}
+
+type PluginProvider struct {
+	name   string
+	path   string
+	Clust  string `survey:"cluster"`
+	Proj   string `survey:"project"`
+	bucket string
+	Reg    string `survey:"region"`
+	ctx    map[string]interface{}
+	writer manifest.Writer
+	client *rpc.Client
+}
+
+var pluginSurvey = []*survey.Question{
+	{
+		Name:     "cluster",
+		Prompt:   &survey.Input{Message: "Enter the name of your cluster:"},
+		Validate: validCluster,
+	},
+	{
+		Name:   "project",
+		Prompt: &survey.Input{Message: "Enter the project or account to deploy to (if applicable):"},
+	},
+	{
+		Name:     "region",
+		Prompt:   &survey.Input{Message: "What region will you deploy to?"},
+		Validate: survey.Required,
+	},
+}
+
+func pluginDir() string {
+	folder, _ := os.UserHomeDir()
+	return pathing.SanitizeFilepath(filepath.Join(folder, ".plural", "plugins"))
+}
+
+func pluginPath(name string) (string, bool) {
+	path := pathing.SanitizeFilepath(filepath.Join(pluginDir(), pluginPrefix+name))
+	// the name comes from workspace.yaml, so it mustn't be able to point outside the plugin dir
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return path, false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return path, false
+	}
+	return path, true
+}
+
+// PluginProviders lists the names of all provider plugins installed in ~/.plural/plugins
+func PluginProviders() []string {
+	names := make([]string, 0)
+	entries, err := ioutil.ReadDir(pluginDir())
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		if name := strings.TrimPrefix(entry.Name(), pluginPrefix); name != entry.Name() {
+			if _, ok := pluginPath(name); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func mkPlugin(conf config.Config, name string) (provider *PluginProvider, err error) {
+	path, ok := pluginPath(name)
+	if !ok {
+		return nil, fmt.Errorf("Invalid provider name: %s", name)
+	}
+
+	provider = &PluginProvider{name: name, path: path, ctx: map[string]interface{}{}}
+	if err = survey.Ask(pluginSurvey, provider); err != nil {
+		return
+	}
+
+	projectManifest := manifest.ProjectManifest{
+		Cluster:  provider.Cluster(),
+		Project:  provider.Project(),
+		Provider: name,
+		Region:   provider.Region(),
+		Context:  provider.Context(),
+		Owner:    &manifest.Owner{Email: conf.Email, Endpoint: conf.Endpoint},
+	}
+
+	provider.writer = projectManifest.Configure()
+	provider.bucket = projectManifest.Bucket
+	return
+}
+
+func pluginFromManifest(man *manifest.ProjectManifest) (*PluginProvider, error) {
+	path, ok := pluginPath(man.Provider)
+	if !ok {
+		return nil, fmt.Errorf("Invalid provider name: %s, if it's a plugin make sure it's installed at %s", man.Provider, path)
+	}
+
+	return &PluginProvider{
+		name:   man.Provider,
+		path:   path,
+		Clust:  man.Cluster,
+		Proj:   man.Project,
+		bucket: man.Bucket,
+		Reg:    man.Region,
+		ctx:    man.Context,
+	}, nil
+}
+
+// running plugins, by path, so every provider for the same plugin shares a single process
+var (
+	plugins   = map[string]*rpc.Client{}
+	pluginsMu sync.Mutex
+)
+
+func (plugin *PluginProvider) call(method string, args interface{}, reply interface{}) error {
+	if plugin.client == nil {
+		client, err := runningPlugin(plugin.path)
+		if err != nil {
+			return fmt.Errorf("failed to start provider plugin %s: %w", plugin.name, err)
+		}
+		plugin.client = client
+	}
+
+	if err := plugin.client.Call(method, args, reply); err != nil {
+		return fmt.Errorf("provider plugin %s failed calling %s: %w", plugin.name, method, err)
+	}
+	return nil
+}
+
+func runningPlugin(path string) (*rpc.Client, error) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if client, ok := plugins[path]; ok {
+		return client, nil
+	}
+
+	client, err := startPlugin(path)
+	if err != nil {
+		return nil, err
+	}
+	plugins[path] = client
+	return client, nil
+}
+
+// StopPlugins closes the stdin of every running plugin and waits for them to exit
+func StopPlugins() {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	for path, client := range plugins {
+		client.Close()
+		delete(plugins, path)
+	}
+}
+
+func startPlugin(path string) (*rpc.Client, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return jsonrpc.NewClient(&pluginConn{stdout, stdin, cmd}), nil
+}
+
+// pluginConn stitches a plugin's stdout and stdin into a single connection for the rpc client
+type pluginConn struct {
+	io.ReadCloser
+	stdin io.WriteCloser
+	cmd   *exec.Cmd
+}
+
+func (conn *pluginConn) Write(p []byte) (int, error) {
+	return conn.stdin.Write(p)
+}
+
+// Close closes the plugin's stdin, which it should exit on, and reaps it.  Wait closes stdout itself.
+func (conn *pluginConn) Close() error {
+	conn.stdin.Close()
+	return conn.cmd.Wait()
+}
+
+func (plugin *PluginProvider) manifest() *PluginManifest {
+	return &PluginManifest{
+		Cluster: plugin.Cluster(),
+		Project: plugin.Project(),
+		Region:  plugin.Region(),
+		Bucket:  plugin.Bucket(),
+		Context: jsonSafe(plugin.Context()),
+	}
+}
+
+func (plugin *PluginProvider) KubeConfig() error {
+	if utils.InKubernetes() {
+		return nil
+	}
+
+	return plugin.call(pluginKubeConfig, &PluginArgs{Manifest: plugin.manifest()}, &PluginReply{})
+}
+
+func (plugin *PluginProvider) CreateBackend(prefix string, ctx map[string]interface{}) (string, error) {
+	ctx["Region"] = plugin.Region()
+	ctx["Bucket"] = plugin.Bucket()
+	ctx["Prefix"] = prefix
+	ctx["__CLUSTER__"] = plugin.Cluster()
+	if _, ok := ctx["Cluster"]; !ok {
+		ctx["Cluster"] = fmt.Sprintf(`"%s"`, plugin.Cluster())
+	}
+
+	reply := &PluginBackendReply{}
+	args := &PluginBackendArgs{Manifest: plugin.manifest(), Prefix: prefix, Context: jsonSafe(ctx)}
+	err := plugin.call(pluginCreateBackend, args, reply)
+	return reply.Backend, err
+}
+
+func (plugin *PluginProvider) Decommision(node *v1.Node) error {
+	return plugin.call(pluginDecommision, &PluginNodeArgs{Manifest: plugin.manifest(), Node: node}, &PluginReply{})
+}
+
+func (plugin *PluginProvider) Preflights() []*Preflight {
+	reply := &PluginPreflightsReply{}
+	if err := plugin.call(pluginPreflights, &PluginArgs{Manifest: plugin.manifest()}, reply); err != nil {
+		return []*Preflight{{Name: "Plugin Available", Callback: func() error { return err }}}
+	}
+
+	preflights := make([]*Preflight, 0)
+	for _, name := range reply.Names {
+		args := &PluginPreflightArgs{Manifest: plugin.manifest(), Name: name}
+		preflights = append(preflights, &Preflight{
+			Name:     name,
+			Callback: func() error { return plugin.call(pluginPreflight, args, &PluginReply{}) },
+		})
+	}
+	return preflights
+}
+
+func (plugin *PluginProvider) Name() string {
+	return plugin.name
+}
+
+func (plugin *PluginProvider) Cluster() string {
+	return plugin.Clust
+}
+
+func (plugin *PluginProvider) Project() string {
+	return plugin.Proj
+}
+
+func (plugin *PluginProvider) Bucket() string {
+	return plugin.bucket
+}
+
+func (plugin *PluginProvider) Region() string {
+	return plugin.Reg
+}
+
+func (plugin *PluginProvider) Context() map[string]interface{} {
+	return plugin.ctx
+}
+
+func (plugin *PluginProvider) Flush() error {
+	if plugin.writer == nil {
+		return nil
+	}
+	return plugin.writer()
+}
+
+// jsonSafe converts the map[interface{}]interface{} values yaml.v2 produces into something encoding/json can handle
+func jsonSafe(ctx map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(ctx))
+	for k, v := range ctx {
+		result[k] = jsonSafeValue(v)
+	}
+	return result
+}
+
+func jsonSafeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return jsonSafe(v)
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			result[fmt.Sprintf("%v", k)] = jsonSafeValue(nested)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, nested := range v {
+			result[i] = jsonSafeValue(nested)
+		}
+		return result
+	default:
+		return v
+	}
+}
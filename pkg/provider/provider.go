@@ -82,6 +82,26 @@ func GetProvider() (Provider, error) {
 	return New(provider)
 }
 
+// ForRepo returns the provider for the cluster target an installed repo deploys to
+func ForRepo(repo string) (Provider, error) {
+	project, err := manifest.ReadProject(manifest.ProjectManifestPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return FromTarget(project, manifest.RepoTarget(repo))
+}
+
+// FromTarget returns the provider for one of a workspace's cluster targets
+func FromTarget(man *manifest.ProjectManifest, target string) (Provider, error) {
+	targeted, err := man.ForTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromManifest(targeted)
+}
+
 func FromManifest(man *manifest.ProjectManifest) (Provider, error) {
 	switch man.Provider {
 	case GCP:
@@ -94,8 +114,10 @@ func FromManifest(man *manifest.ProjectManifest) (Provider, error) {
 		return equinixFromManifest(man)
 	case KIND:
 		return kindFromManifest(man)
+	case GENERIC:
+		return genericFromManifest(man)
 	default:
-		return nil, fmt.Errorf("Invalid provider name: %s", man.Provider)
+		return pluginFromManifest(man)
 	}
 }
 
@@ -112,8 +134,10 @@ func New(provider string) (Provider, error) {
 		return mkEquinix(conf)
 	case KIND:
 		return mkKind(conf)
+	case GENERIC:
+		return mkGeneric(conf)
 	default:
-		return nil, fmt.Errorf("Invalid provider name: %s", provider)
+		return mkPlugin(conf, provider)
 	}
 }
 
@@ -130,7 +154,7 @@ func getAvailableProviders() error {
 		if err != nil {
 			return err
 		}
-		providers.AvailableProviders = available
+		providers.AvailableProviders = append(append(available, GENERIC), PluginProviders()...)
 	}
 	return nil
 }
@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"fmt"
+)
+
+// SecretStore is implemented by providers with a native secret manager, and is used to escrow
+// sensitive workspace state like the aes key outside of the local machine
+type SecretStore interface {
+	StoreSecret(name string, value []byte) error
+	FetchSecret(name string) ([]byte, error)
+}
+
+func GetSecretStore(prov Provider) (SecretStore, error) {
+	if store, ok := prov.(SecretStore); ok {
+		return store, nil
+	}
+
+	return nil, fmt.Errorf("The %s provider does not support a native secret store", prov.Name())
+}
@@ -11,10 +11,10 @@ import (
 
 	"github.com/imdario/mergo"
 	"github.com/pluralsh/plural/pkg/api"
-	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/helm"
 	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/overlay"
 	"github.com/pluralsh/plural/pkg/provider"
-	"github.com/pluralsh/plural/pkg/template"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/errors"
 	"github.com/pluralsh/plural/pkg/utils/git"
@@ -94,12 +94,13 @@ func Notes(installation *api.Installation) error {
 		return err
 	}
 
-	prov, err := provider.GetProvider()
+	repo := installation.Repository.Name
+	prov, err := provider.ForRepo(repo)
 	if err != nil {
 		return err
 	}
 
-	repo := installation.Repository.Name
+	conf := manifest.RepoConfig(repo)
 	ctx, _ := context.Repo(installation.Repository.Name)
 	valuesFile := pathing.SanitizeFilepath(filepath.Join(repoRoot, repo, "helm", repo, "values.yaml"))
 	prevVals, _ := prevValues(valuesFile)
@@ -111,7 +112,7 @@ func Notes(installation *api.Installation) error {
 		"Region":        prov.Region(),
 		"Project":       prov.Project(),
 		"Cluster":       prov.Cluster(),
-		"Config":        config.Read(),
+		"Config":        conf,
 		"Provider":      prov.Name(),
 		"Context":       prov.Context(),
 		"Applications":  BuildApplications(repoRoot),
@@ -132,14 +133,9 @@ func Notes(installation *api.Installation) error {
 		vals[k] = v
 	}
 
-	tmpl, err := template.MakeTemplate(installation.Repository.Notes)
-	if err != nil {
-		return err
-	}
-
 	var buf bytes.Buffer
 	buf.Grow(5 * 1024)
-	if err := tmpl.Execute(&buf, vals); err != nil {
+	if err := executeTemplate(&buf, installation.Repository.Notes, repo, false, &conf, vals); err != nil {
 		return err
 	}
 
@@ -153,9 +149,19 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 	values := make(map[string]map[string]interface{})
 	buf.Grow(5 * 1024)
 
-	valuesFile := pathing.SanitizeFilepath(filepath.Join(s.Root, "values.yaml"))
-	prevVals, _ := prevValues(valuesFile)
-	conf := config.Read()
+	valuesFile := pathing.SanitizeFilepath(filepath.Join(s.Root, overlay.ValuesFile))
+	generatedFile := pathing.SanitizeFilepath(filepath.Join(s.Root, overlay.GeneratedFile))
+	overrideFile := pathing.SanitizeFilepath(filepath.Join(s.Root, overlay.OverrideFile))
+
+	// the last generated values keep things like random passwords stable, workspaces built before
+	// values were layered only have the merged values.yaml to go off
+	firstLayered := !utils.Exists(generatedFile)
+	prevFile := generatedFile
+	if firstLayered {
+		prevFile = valuesFile
+	}
+	prevVals, _ := prevValues(prevFile)
+	conf := *w.Config
 	globals := map[string]interface{}{}
 
 	apps, err := NewApplications()
@@ -169,7 +175,7 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 	}
 
 	for _, chartInst := range w.Charts {
-		tplate := chartInst.Version.ValuesTemplate
+		tplate, linked := chartInst.Version.ValuesTemplate, false
 		if w.Links != nil {
 			if path, ok := w.Links.Helm[chartInst.Chart.Name]; ok {
 				var err error
@@ -177,14 +183,10 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 				if err != nil {
 					return err
 				}
+				linked = true
 			}
 		}
 
-		tmpl, err := template.MakeTemplate(tplate)
-		if err != nil {
-			return err
-		}
-
 		vals := map[string]interface{}{
 			"Values":        ctx,
 			"Configuration": w.Context.Configuration,
@@ -215,7 +217,7 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 			vals[k] = v
 		}
 
-		if err := tmpl.Execute(&buf, vals); err != nil {
+		if err := executeTemplate(&buf, tplate, w.Installation.Repository.Name, linked, &conf, vals); err != nil {
 			return err
 		}
 
@@ -236,18 +238,53 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 		buf.Reset()
 	}
 
-	if err := mergo.Merge(&values, prevVals); err != nil {
-		return err
+	generated := map[string]interface{}{}
+	for name, vals := range values {
+		generated[name] = vals
 	}
 
 	if len(globals) > 0 {
-		values["global"] = globals
+		generated["global"] = globals
 	}
 
-	values["plrl"] = map[string]interface{}{
+	generated["plrl"] = map[string]interface{}{
 		"license": w.Installation.LicenseKey,
 	}
+	generated = overlay.Normalize(generated)
 
+	if err := writeValues(generatedFile, generated); err != nil {
+		return err
+	}
+
+	// carry any hand edits to the old merged values.yaml over into the override layer, so they aren't lost
+	if firstLayered && !utils.Exists(overrideFile) && utils.Exists(valuesFile) {
+		previous, err := overlay.Read(valuesFile)
+		if err != nil {
+			return err
+		}
+
+		if edits := overlay.Diff(previous, generated); len(edits) > 0 {
+			utils.Note("Moving values you've set by hand in %s into %s\n", valuesFile, overrideFile)
+			if err := writeValues(overrideFile, edits); err != nil {
+				return err
+			}
+		}
+	}
+
+	// every layer can hold secrets, so make sure they're encrypted even in workspaces set up before layering
+	if err := buildSecrets(pathing.SanitizeFilepath(filepath.Join(s.Root, ".gitattributes")), []string{"values.*.yaml"}); err != nil {
+		return err
+	}
+
+	layers, err := overlay.Layers(s.Root, proj.Environment, generated)
+	if err != nil {
+		return err
+	}
+
+	return writeValues(valuesFile, overlay.Merge(layers))
+}
+
+func writeValues(path string, values map[string]interface{}) error {
 	io, err := yaml.Marshal(values)
 	if err != nil {
 		fmt.Println("Invalid yaml:\n")
@@ -255,7 +292,46 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 		return err
 	}
 
-	return utils.WriteFile(valuesFile, io)
+	return utils.WriteFile(path, io)
+}
+
+func (s *Scaffold) validateValues(w *wkspace.Workspace) error {
+	repo := w.Installation.Repository.Name
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	vals, err := wkspace.RenderValues(root, repo)
+	if err != nil {
+		return err
+	}
+
+	utils.Highlight("validating helm values for %s ~> ", repo)
+	invalid, unknown, err := helm.ValidateValues(s.Root, vals)
+	if err != nil {
+		fmt.Print("\n")
+		return err
+	}
+
+	if len(invalid) == 0 && len(unknown) == 0 {
+		utils.Success("\u2713\n")
+		return nil
+	}
+
+	fmt.Print("\n")
+	for _, unknownErr := range unknown {
+		utils.Warn("%s: %s, it will be ignored\n", unknownErr.Path, unknownErr.Message)
+	}
+
+	if len(invalid) == 0 {
+		return nil
+	}
+
+	for _, invalidErr := range invalid {
+		utils.Error("%s: %s\n", invalidErr.Path, invalidErr.Message)
+	}
+	return fmt.Errorf("The helm values for %s don't match the values.schema.json of their charts, fix them in %s", repo, overlay.OverrideFile)
 }
 
 func prevValues(filename string) (map[string]map[string]interface{}, error) {
@@ -0,0 +1,151 @@
+package scaffold
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const generatedHeader = `# Generated by plural, edits outside of the manual sections below are overwritten on every build.
+# Put any terraform of your own in extra.tf or a *.override.tf file alongside this one instead.
+`
+
+var (
+	checksumLine   = regexp.MustCompile(`(?m)^# plural-checksum: ([0-9a-f]+)\n`)
+	manualSections = regexp.MustCompile(`(?s)### BEGIN MANUAL SECTION <<([^>]*)>>(.*?)### END MANUAL SECTION <<[^>]*>>`)
+)
+
+// userOwned is true for terraform files scaffolding never writes to, so users can extend a repo's terraform safely
+func userOwned(name string) bool {
+	return name == "extra.tf" || name == "override.tf" ||
+		strings.HasSuffix(name, ".override.tf") || strings.HasSuffix(name, "_override.tf")
+}
+
+// userFiles lists the user owned terraform files in dir
+func userFiles(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && userOwned(entry.Name()) {
+			files = append(files, entry.Name())
+		}
+	}
+	return files
+}
+
+// checksum hashes generated content, ignoring whatever is in its manual sections as users are free to edit those
+func checksum(contents string) string {
+	contents = checksumLine.ReplaceAllString(contents, "")
+	contents = manualSections.ReplaceAllString(contents, "### MANUAL SECTION <<$1>>")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))
+}
+
+// writeGenerated overwrites path with freshly generated contents.  If the previous contents were edited outside
+// their manual sections since plural last wrote them, they're backed up to path.orig and the lines that won't
+// survive the rewrite are recorded for the build summary.
+func (s *Scaffold) writeGenerated(path, contents string) error {
+	contents = strings.TrimRight(generatedHeader+contents, "\n") + "\n"
+	contents = fmt.Sprintf("%s# plural-checksum: %s\n", contents, checksum(contents))
+
+	prev, err := utils.ReadFile(path)
+	if err != nil {
+		return utils.WriteFile(path, []byte(contents))
+	}
+
+	// files written before checksums were added can't be checked for edits
+	if match := checksumLine.FindStringSubmatch(prev); match != nil && match[1] != checksum(prev) {
+		if err := ioutil.WriteFile(path+".orig", []byte(prev), 0644); err != nil {
+			return err
+		}
+
+		name := filepath.Base(path)
+		utils.Warn("%s was edited outside of its manual sections, backing it up to %s.orig before regenerating it\n", name, name)
+		for _, line := range lostLines(prev, contents) {
+			s.discard(fmt.Sprintf("%s: %s", name, line))
+		}
+	}
+
+	return utils.WriteFile(path, []byte(contents))
+}
+
+// orphanedSections backs up the file at path if it has manual sections for modules that are no longer installed,
+// since they'd be dropped when it's regenerated
+func (s *Scaffold) orphanedSections(path string, modules []string) error {
+	contents, err := utils.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	installed := map[string]bool{}
+	for _, module := range modules {
+		installed[module] = true
+	}
+
+	orphaned := false
+	name := filepath.Base(path)
+	for _, match := range manualSections.FindAllStringSubmatch(contents, -1) {
+		if module, body := match[1], strings.TrimSpace(match[2]); !installed[module] && body != "" {
+			s.discard(fmt.Sprintf("%s: the manual section of module %s, which is no longer installed:\n%s", name, module, body))
+			orphaned = true
+		}
+	}
+
+	if !orphaned {
+		return nil
+	}
+	return ioutil.WriteFile(path+".orig", []byte(contents), 0644)
+}
+
+func (s *Scaffold) discard(content string) {
+	s.discarded = append(s.discarded, content)
+}
+
+func lostLines(prev, next string) []string {
+	kept := map[string]bool{}
+	for _, line := range strings.Split(next, "\n") {
+		kept[strings.TrimSpace(line)] = true
+	}
+
+	lost := make([]string, 0)
+	for _, line := range strings.Split(prev, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !kept[line] && !checksumLine.MatchString(line+"\n") {
+			lost = append(lost, line)
+		}
+	}
+	return lost
+}
+
+// summarize prints anything the build regenerated over, and the user owned files it left alone
+func (b *Build) summarize(root string) {
+	discarded := make([]string, 0)
+	for _, s := range b.Scaffolds {
+		discarded = append(discarded, s.discarded...)
+	}
+
+	files := userFiles(filepath.Join(root, b.Metadata.Name, "terraform"))
+	sort.Strings(files)
+	if len(files) > 0 {
+		utils.Note("Kept your terraform in %s\n", strings.Join(files, ", "))
+	}
+
+	if len(discarded) == 0 {
+		return
+	}
+
+	utils.Warn("\nThis build discarded content you added by hand, backups were saved alongside with a .orig suffix:\n")
+	for _, content := range discarded {
+		fmt.Printf("  - %s\n", content)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,81 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/template"
+)
+
+// executeTemplate renders one of repo's templates into buf, with conf the config of the cluster target it deploys to.  Templates linked from a local path are the user's own
+// so they're always privileged, otherwise the repo has to be trusted in workspace.yaml, and the first time an
+// untrusted repo calls a privileged function the user is asked whether to trust it from now on.
+func executeTemplate(buf *bytes.Buffer, tplate, repo string, local bool, conf *config.Config, vals map[string]interface{}) error {
+	proj, _ := manifest.FetchProject()
+	privileged := local || proj.Privileged(repo)
+	start := buf.Len()
+	err := execute(buf, tplate, privileged, conf, vals)
+
+	var denied *template.DeniedError
+	if privileged || !errors.As(err, &denied) {
+		return err
+	}
+
+	trusted, terr := trustRepo(repo, denied.Func)
+	if terr != nil {
+		return terr
+	}
+	if !trusted {
+		return err
+	}
+
+	buf.Truncate(start)
+	return execute(buf, tplate, true, conf, vals)
+}
+
+func execute(buf *bytes.Buffer, tplate string, privileged bool, conf *config.Config, vals map[string]interface{}) error {
+	if !privileged {
+		vals = redacted(vals)
+	}
+
+	tmpl, err := template.MakeTargetTemplate(tplate, privileged, conf)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(buf, vals)
+}
+
+// trustRepo asks whether repo can use privileged functions, saving it to workspace.yaml if so.  It's never trusted
+// without an answer, eg when there's no terminal to prompt on.
+func trustRepo(repo, fn string) (bool, error) {
+	trust := false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("%s's templates call %s, which can read local files, secrets or prompt for input. Do you trust %s to use privileged template functions?", repo, fn, repo),
+		Default: false,
+	}
+	if err := survey.AskOne(prompt, &trust); err != nil || !trust {
+		return false, nil
+	}
+
+	return true, manifest.Trust(repo)
+}
+
+// redacted swaps the config in a template's values for one without credentials, which only privileged templates get
+func redacted(vals map[string]interface{}) map[string]interface{} {
+	safe := make(map[string]interface{}, len(vals))
+	for k, v := range vals {
+		safe[k] = v
+	}
+
+	switch conf := vals["Config"].(type) {
+	case config.Config:
+		safe["Config"] = conf.Redacted()
+	case *config.Config:
+		safe["Config"] = conf.Redacted()
+	}
+	return safe
+}
@@ -20,6 +20,9 @@ type Scaffold struct {
 	Root string `hcle:"omit"`
 
 	Preflight []*executor.Step `hcl:"preflight"`
+
+	// hand written content the last execution overwrote
+	discarded []string `hcle:"omit"`
 }
 
 type Metadata struct {
@@ -142,6 +145,11 @@ func (s *Scaffold) Execute(wk *wkspace.Workspace, force bool) error {
 		preflight.Sha = sha
 	}
 
+	// values can only be checked once the preflights have pulled down the chart's dependencies
+	if s.Type == HELM {
+		return s.validateValues(wk)
+	}
+
 	return nil
 }
 
@@ -177,5 +185,6 @@ func (b *Build) Execute(wk *wkspace.Workspace, force bool) error {
 		}
 	}
 
+	b.summarize(root)
 	return b.Flush(root)
 }
@@ -84,10 +84,6 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 			}
 		}
 
-		tmpl, err := template.MakeTemplate(plate)
-		if err != nil {
-			return err
-		}
 		values := map[string]interface{}{
 			"Values":        ctx,
 			"Configuration": wk.Context.Configuration,
@@ -98,7 +94,7 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 			"Context":       wk.Provider.Context(),
 			"Applications":  apps,
 		}
-		if err := tmpl.Execute(&buf, values); err != nil {
+		if err := executeTemplate(&buf, plate, repo.Name, linkPath != "", wk.Config, values); err != nil {
 			return err
 		}
 
@@ -133,7 +129,16 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 		buf.Reset()
 	}
 
-	if err := utils.WriteFile(mainFile, []byte(strings.Join(modules, "\n\n"))); err != nil {
+	names := make([]string, len(wk.Terraform))
+	for i, tfInst := range wk.Terraform {
+		names[i] = tfInst.Terraform.Name
+	}
+
+	if err := scaffold.orphanedSections(mainFile, names); err != nil {
+		return err
+	}
+
+	if err := scaffold.writeGenerated(mainFile, strings.Join(modules, "\n\n")); err != nil {
 		return err
 	}
 
@@ -197,7 +202,7 @@ func (scaffold *Scaffold) buildOutputs(wk *wkspace.Workspace) error {
 	}
 
 	outputFile := pathing.SanitizeFilepath(filepath.Join(scaffold.Root, "outputs.tf"))
-	return utils.WriteFile(outputFile, buf.Bytes())
+	return scaffold.writeGenerated(outputFile, buf.String())
 }
 
 func untar(v *api.Version, tf *api.Terraform, dir string) error {
@@ -0,0 +1,321 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// FixtureDir holds the fixtures of the template in the directory above it
+	FixtureDir      = "tests"
+	GoldenExtension = ".golden"
+
+	helmTemplate      = "values.yaml.tpl"
+	terraformTemplate = "terraform.tfvars"
+)
+
+// Fixture describes the installation a values template is rendered for in a test, eg tests/aws.yaml next to a chart's
+// values.yaml.tpl.  Its expected output lives alongside it in tests/aws.golden.
+type Fixture struct {
+	// relative to the directory above the fixture, defaults to values.yaml.tpl or terraform.tfvars, whichever exists
+	Template      string
+	Privileged    bool
+	Values        map[string]interface{}
+	Configuration map[string]map[string]interface{}
+	License       string
+	OIDC          map[string]interface{} `yaml:"oidc"`
+	Provider      string
+	Region        string
+	Project       string
+	Cluster       string
+	Namespace     string
+	Context       map[string]interface{}
+	Network       *manifest.NetworkConfig
+	Config        config.Config
+	SMTP          map[string]interface{} `yaml:"smtp"`
+	Acme          map[string]string
+	// helm values and terraform outputs of other applications, by application name
+	Applications struct {
+		Helm      map[string]map[string]interface{}
+		Terraform map[string]map[string]interface{}
+	}
+	Stubs Stubs
+
+	path string
+}
+
+// Stubs are what the functions reading the git checkout, the machine, the cluster or the user's input return in a
+// fixture, so it renders the same everywhere, including offline in CI
+type Stubs struct {
+	Repo struct {
+		Root   string
+		Name   string
+		Url    string
+		Branch string
+	}
+	// answers to readLine, readPassword and readLineDefault, by prompt
+	Inputs map[string]string
+	// contents of the files readFile and fileExists see, by path
+	Files      map[string]string
+	Home       string
+	KnownHosts string `yaml:"knownHosts"`
+	AesKey     string `yaml:"aesKey"`
+	// secrets by namespace/name
+	Secrets map[string]map[string]interface{}
+	Env     map[string]string
+	// the time now returns, in RFC 3339, defaults to the start of 2022
+	Now string
+}
+
+// FixtureApplications stands in for the workspace's applications while rendering a fixture
+type FixtureApplications struct {
+	fixture *Fixture
+}
+
+func (apps *FixtureApplications) HelmValues(app string) (map[string]interface{}, error) {
+	return apps.fixture.Applications.Helm[app], nil
+}
+
+func (apps *FixtureApplications) TerraformValues(app string) (map[string]interface{}, error) {
+	return apps.fixture.Applications.Terraform[app], nil
+}
+
+// FindFixtures lists every fixture under root, sorted by path
+func FindFixtures(root string) ([]string, error) {
+	fixtures := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			// helm keeps test hooks in templates/tests, which aren't fixtures
+			if name := info.Name(); path != root && (name == ".git" || name == "charts" || name == ".terraform" || name == "templates") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if filepath.Base(filepath.Dir(path)) == FixtureDir && (ext == ".yaml" || ext == ".yml") && besideTemplate(filepath.Dir(path)) {
+			fixtures = append(fixtures, path)
+		}
+		return nil
+	})
+	sort.Strings(fixtures)
+	return fixtures, err
+}
+
+// besideTemplate is true if the fixture dir sits next to a template it could be testing
+func besideTemplate(dir string) bool {
+	parent := filepath.Dir(dir)
+	return utils.Exists(pathing.SanitizeFilepath(filepath.Join(parent, helmTemplate))) ||
+		utils.Exists(pathing.SanitizeFilepath(filepath.Join(parent, terraformTemplate)))
+}
+
+func ReadFixture(path string) (*Fixture, error) {
+	contents, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{path: path}
+	if err := yaml.UnmarshalStrict([]byte(contents), fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// GoldenPath is where the expected output of the fixture is kept
+func (f *Fixture) GoldenPath() string {
+	return strings.TrimSuffix(f.path, filepath.Ext(f.path)) + GoldenExtension
+}
+
+// TemplatePath is the template the fixture tests
+func (f *Fixture) TemplatePath() string {
+	dir := filepath.Dir(filepath.Dir(f.path))
+	if f.Template != "" {
+		return pathing.SanitizeFilepath(filepath.Join(dir, f.Template))
+	}
+
+	if path := pathing.SanitizeFilepath(filepath.Join(dir, helmTemplate)); utils.Exists(path) {
+		return path
+	}
+	return pathing.SanitizeFilepath(filepath.Join(dir, terraformTemplate))
+}
+
+// Render executes the fixture's template entirely offline, with the values the fixture describes in place of the ones
+// a workspace would provide
+func (f *Fixture) Render() (string, error) {
+	path := f.TemplatePath()
+	contents, err := utils.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	funcs, err := f.funcs()
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("gotpl").Funcs(funcMap(f.Privileged)).Funcs(funcs).Parse(contents)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, f.values(filepath.Base(path) == terraformTemplate)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// funcs replaces the functions that would read the workspace, git or the network with ones reading the fixture, and
+// makes the random ones repeatable so golden files stay stable
+func (f *Fixture) funcs() (template.FuncMap, error) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	if f.Stubs.Now != "" {
+		parsed, err := time.Parse(time.RFC3339, f.Stubs.Now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid now in fixture %s: %w", f.path, err)
+		}
+		now = parsed
+	}
+
+	funcs := template.FuncMap{
+		"namespace": f.Config.Namespace,
+		"lookupOutput": func(repo, name string) (interface{}, error) {
+			return f.Applications.Terraform[repo][name], nil
+		},
+		"repoRoot":      func() string { return f.Stubs.Repo.Root },
+		"repoName":      func() string { return f.Stubs.Repo.Name },
+		"repoUrl":       func() string { return f.Stubs.Repo.Url },
+		"branchName":    func() string { return f.Stubs.Repo.Branch },
+		"randAlphaNum":  placeholder("x"),
+		"randAlpha":     placeholder("x"),
+		"randNumeric":   placeholder("0"),
+		"randAscii":     placeholder("x"),
+		"randBytes":     func(count int) string { return base64.StdEncoding.EncodeToString(make([]byte, count)) },
+		"randInt":       func(min, max int) int { return min },
+		"shuffle":       func(str string) string { return str },
+		"uuidv4":        func() string { return "00000000-0000-4000-8000-000000000000" },
+		"now":           func() time.Time { return now },
+		"ago":           func(interface{}) string { return "0s" },
+		"genAESKey":     func() string { return "fixture-aes-key" },
+		"genPrivateKey": func(string) string { return "fixture-private-key" },
+		"bcrypt":        func(string) string { return "fixture-bcrypt-hash" },
+		"htpasswd":      func(user, password string) string { return user + ":fixture-bcrypt-hash" },
+		"encryptAES":    func(password, plaintext string) string { return "fixture-encrypted" },
+	}
+	for _, name := range []string{"genCA", "genCAWithKey", "genSelfSignedCert", "genSelfSignedCertWithKey", "genSignedCert", "genSignedCertWithKey"} {
+		funcs[name] = func(...interface{}) map[string]string {
+			return map[string]string{"Cert": "fixture-cert", "Key": "fixture-key"}
+		}
+	}
+
+	// a fixture that isn't privileged should still see privileged functions denied
+	if !f.Privileged {
+		return funcs, nil
+	}
+
+	funcs["dumpConfig"] = func() (string, error) {
+		io, err := f.Config.Marshal()
+		return string(io), err
+	}
+	funcs["dumpAesKey"] = func() (string, error) {
+		io, err := (&crypto.AESKey{Key: f.Stubs.AesKey}).Marshal()
+		return string(io), err
+	}
+	funcs["readLine"] = f.input
+	funcs["readPassword"] = f.input
+	funcs["readLineDefault"] = func(prompt, def string) string {
+		if answer, ok := f.Stubs.Inputs[prompt]; ok {
+			return answer
+		}
+		return def
+	}
+	funcs["readFile"] = func(path string) string { return f.Stubs.Files[path] }
+	funcs["fileExists"] = func(path string) bool {
+		_, ok := f.Stubs.Files[path]
+		return ok
+	}
+	funcs["homeDir"] = func(parts ...string) string { return path.Join(f.Stubs.Home, path.Join(parts...)) }
+	funcs["knownHosts"] = func() string { return f.Stubs.KnownHosts }
+	funcs["secret"] = func(namespace, name string) map[string]interface{} {
+		if secret, ok := f.Stubs.Secrets[namespace+"/"+name]; ok {
+			return secret
+		}
+		return map[string]interface{}{}
+	}
+	funcs["eabCredential"] = func(cluster, provider string) *api.EabCredential {
+		return &api.EabCredential{KeyId: "fixture-key-id", HmacKey: "fixture-hmac-key", Cluster: cluster, Provider: provider}
+	}
+	funcs["env"] = func(name string) string { return f.Stubs.Env[name] }
+	funcs["expandenv"] = func(s string) string { return os.Expand(s, func(name string) string { return f.Stubs.Env[name] }) }
+	funcs["getHostByName"] = func(string) string { return "127.0.0.1" }
+	return funcs, nil
+}
+
+func (f *Fixture) input(prompt string) (string, error) {
+	answer, ok := f.Stubs.Inputs[prompt]
+	if !ok {
+		return "", fmt.Errorf("no answer to %q in the inputs of the fixture's stubs", prompt)
+	}
+	return answer, nil
+}
+
+func placeholder(char string) func(int) string {
+	return func(count int) string {
+		return strings.Repeat(char, count)
+	}
+}
+
+// values mirrors what scaffolding passes to helm and terraform values templates
+func (f *Fixture) values(terraform bool) map[string]interface{} {
+	vals := map[string]interface{}{
+		"Values":        f.Values,
+		"Configuration": f.Configuration,
+		"Region":        f.Region,
+		"Project":       f.Project,
+		"Cluster":       f.Cluster,
+		"Context":       f.Context,
+		"Applications":  &FixtureApplications{fixture: f},
+	}
+
+	if terraform {
+		vals["Namespace"] = f.Namespace
+		return vals
+	}
+
+	vals["License"] = f.License
+	vals["OIDC"] = f.OIDC
+	vals["Config"] = &f.Config
+	if !f.Privileged {
+		vals["Config"] = f.Config.Redacted()
+	}
+	vals["Provider"] = f.Provider
+	vals["Network"] = f.Network
+	if f.SMTP != nil {
+		vals["SMTP"] = f.SMTP
+	}
+	if f.Acme != nil {
+		vals["Acme"] = f.Acme
+	}
+	return vals
+}
@@ -2,15 +2,21 @@ package template
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/apparentlymart/go-cidr/cidr"
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/output"
 	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
 	"gopkg.in/yaml.v2"
 )
 
@@ -33,10 +39,21 @@ func repoName() string {
 	return path.Base(root)
 }
 
+// repoUrl is the url of the origin remote, without any credentials embedded in it
 func repoUrl() string {
 	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
 	res, _ := cmd.CombinedOutput()
-	return strings.TrimSpace(string(res))
+	return stripCredentials(strings.TrimSpace(string(res)))
+}
+
+func stripCredentials(remote string) string {
+	parsed, err := url.Parse(remote)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.User == nil {
+		return remote
+	}
+
+	parsed.User = nil
+	return parsed.String()
 }
 
 func branchName() string {
@@ -165,3 +182,49 @@ func eabCredential(cluster, provider string) (*api.EabCredential, error) {
 	client := api.NewClient()
 	return client.GetEabCredential(cluster, provider)
 }
+
+// cidrSubnet calculates a subnet of prefix like terraform's cidrsubnet, eg cidrSubnet "10.0.0.0/16" 8 2 is 10.0.2.0/24
+func cidrSubnet(prefix string, newbits, netnum int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	subnet, err := cidr.Subnet(network, newbits, netnum)
+	if err != nil {
+		return "", err
+	}
+	return subnet.String(), nil
+}
+
+// cidrHost calculates the address of host number hostnum in prefix like terraform's cidrhost
+func cidrHost(prefix string, hostnum int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	ip, err := cidr.Host(network, hostnum)
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// lookupOutput reads a terraform output of one of the workspace's repos, it's nil until the repo has been deployed
+func lookupOutput(repo, name string) (interface{}, error) {
+	if repo == "" || strings.ContainsAny(repo, `/\`) || strings.Contains(repo, "..") {
+		return nil, fmt.Errorf("invalid repo %s", repo)
+	}
+
+	root, found := utils.ProjectRoot()
+	if !found {
+		return nil, fmt.Errorf("could not find the root of your workspace")
+	}
+
+	out, err := output.Read(pathing.SanitizeFilepath(filepath.Join(root, repo, "output.yaml")))
+	if err != nil {
+		return nil, nil
+	}
+	return out.Terraform[name], nil
+}
@@ -2,39 +2,115 @@ package template
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
+	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/utils"
 )
 
-func MakeTemplate(tmplate string) (*template.Template, error) {
+// sprig functions that read the environment or network of the machine rendering the template
+var impureSprig = []string{"env", "expandenv", "getHostByName"}
+
+// pureFuncs only compute from their arguments and the workspace's own outputs, so they're safe to
+// give templates fetched from the api
+func pureFuncs() template.FuncMap {
 	funcs := sprig.TxtFuncMap()
+	for _, name := range impureSprig {
+		delete(funcs, name)
+	}
+
 	funcs["genAESKey"] = utils.GenAESKey
-	funcs["repoRoot"] = repoRoot
-	funcs["repoName"] = repoName
-	funcs["repoUrl"] = repoUrl
-	funcs["branchName"] = branchName
-	funcs["dumpConfig"] = dumpConfig
-	funcs["dumpAesKey"] = dumpAesKey
-	funcs["readLine"] = readLine
-	funcs["readPassword"] = readPassword
-	funcs["readLineDefault"] = readLineDefault
-	funcs["readFile"] = readFile
-	funcs["homeDir"] = homeDir
-	funcs["knownHosts"] = knownHosts
 	funcs["dedupe"] = dedupe
 	funcs["dedupeObj"] = dedupeObj
-	funcs["secret"] = secret
 	funcs["probe"] = probe
 	funcs["importValue"] = importValue
 	funcs["namespace"] = namespace
 	funcs["toYaml"] = toYaml
-	funcs["fileExists"] = fileExists
 	funcs["pathJoin"] = pathJoin
-	funcs["eabCredential"] = eabCredential
-	return template.New("gotpl").Funcs(funcs).Parse(tmplate)
+	funcs["cidrSubnet"] = cidrSubnet
+	funcs["cidrHost"] = cidrHost
+	funcs["lookupOutput"] = lookupOutput
+	funcs["repoRoot"] = repoRoot
+	funcs["repoName"] = repoName
+	funcs["repoUrl"] = repoUrl
+	funcs["branchName"] = branchName
+	return funcs
+}
+
+// privilegedFuncs read local files, secrets and credentials or prompt for input, so templates have to be
+// explicitly trusted to use them
+func privilegedFuncs() template.FuncMap {
+	sprigFuncs := sprig.TxtFuncMap()
+	funcs := template.FuncMap{
+		"dumpConfig":      dumpConfig,
+		"dumpAesKey":      dumpAesKey,
+		"readLine":        readLine,
+		"readPassword":    readPassword,
+		"readLineDefault": readLineDefault,
+		"readFile":        readFile,
+		"homeDir":         homeDir,
+		"knownHosts":      knownHosts,
+		"secret":          secret,
+		"fileExists":      fileExists,
+		"eabCredential":   eabCredential,
+	}
+	for _, name := range impureSprig {
+		funcs[name] = sprigFuncs[name]
+	}
+	return funcs
+}
+
+// funcMap is every function a template can call, with the privileged ones failing when executed unless privileged is set
+func funcMap(privileged bool) template.FuncMap {
+	funcs := pureFuncs()
+	for name, fn := range privilegedFuncs() {
+		if privileged {
+			funcs[name] = fn
+		} else {
+			funcs[name] = denied(name)
+		}
+	}
+	return funcs
+}
+
+// MakeTemplate parses a template with only the pure functions available, any privileged function it calls
+// fails when executed
+func MakeTemplate(tmplate string) (*template.Template, error) {
+	return MakeTemplateFor(tmplate, false)
+}
+
+// MakePrivilegedTemplate parses a trusted template, which can use the privileged functions as well as the pure ones
+func MakePrivilegedTemplate(tmplate string) (*template.Template, error) {
+	return MakeTemplateFor(tmplate, true)
+}
+
+// MakeTemplateFor parses a template with the privileged functions only if privileged is set
+func MakeTemplateFor(tmplate string, privileged bool) (*template.Template, error) {
+	return template.New("gotpl").Funcs(funcMap(privileged)).Parse(tmplate)
+}
+
+// MakeTargetTemplate is MakeTemplateFor with namespace resolved against conf, the config of the cluster target the
+// template's repo deploys to, rather than the cli config
+func MakeTargetTemplate(tmplate string, privileged bool, conf *config.Config) (*template.Template, error) {
+	return template.New("gotpl").Funcs(funcMap(privileged)).Funcs(template.FuncMap{"namespace": conf.Namespace}).Parse(tmplate)
+}
+
+// DeniedError is returned when a template that isn't privileged calls a privileged function
+type DeniedError struct {
+	Func string
+}
+
+func (err *DeniedError) Error() string {
+	return fmt.Sprintf("%s is a privileged template function, add the repo to privilegedRepos in workspace.yaml if you trust it to use it", err.Func)
+}
+
+func denied(name string) func(...interface{}) (interface{}, error) {
+	return func(...interface{}) (interface{}, error) {
+		return nil, &DeniedError{Func: name}
+	}
 }
 
 func RenderTemplate(wr io.Writer, tmplate string, ctx map[string]interface{}) error {
@@ -0,0 +1,64 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-version"
+	install "github.com/hashicorp/hc-install"
+	"github.com/hashicorp/hc-install/fs"
+	"github.com/hashicorp/hc-install/product"
+	"github.com/hashicorp/hc-install/releases"
+	"github.com/hashicorp/hc-install/src"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// DefaultVersion is installed when a workspace doesn't pin a terraform version and none is on the PATH
+const DefaultVersion = "1.2.9"
+
+func installDir(v *version.Version) string {
+	folder, _ := os.UserHomeDir()
+	return pathing.SanitizeFilepath(filepath.Join(folder, ".plural", "bin", "terraform", v.String()))
+}
+
+// Binary finds the terraform executable to run for this workspace, downloading the version pinned in
+// workspace.yaml into ~/.plural/bin if it isn't already installed
+func Binary() (string, error) {
+	pinned := ""
+	if project, err := manifest.FetchProject(); err == nil {
+		pinned = project.TerraformVersion
+	}
+
+	return Ensure(pinned)
+}
+
+// Ensure returns the path to a terraform binary of exactly version v, or any installed terraform if v is empty
+func Ensure(v string) (string, error) {
+	ctx := context.Background()
+	installer := install.NewInstaller()
+	if v == "" {
+		path, err := installer.Ensure(ctx, []src.Source{&fs.AnyVersion{Product: &product.Terraform}})
+		if err == nil {
+			return path, nil
+		}
+		v = DefaultVersion
+	}
+
+	ver, err := version.NewVersion(v)
+	if err != nil {
+		return "", fmt.Errorf("invalid terraform version %s: %w", v, err)
+	}
+
+	dir := installDir(ver)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return installer.Ensure(ctx, []src.Source{
+		&fs.ExactVersion{Product: product.Terraform, Version: ver, ExtraPaths: []string{dir}},
+		&releases.ExactVersion{Product: product.Terraform, Version: ver, InstallDir: dir},
+	})
+}
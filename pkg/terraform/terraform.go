@@ -0,0 +1,180 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// Terraform runs terraform commands against the module in a single directory, parsing their json output
+type Terraform struct {
+	tf  *tfexec.Terraform
+	ctx context.Context
+}
+
+// ResourceChange is a single planned action against a resource, eg create or delete
+type ResourceChange struct {
+	Address string
+	Action  string
+}
+
+func New(dir string) (*Terraform, error) {
+	path, err := Binary()
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := tfexec.NewTerraform(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	return &Terraform{tf: tf, ctx: context.Background()}, nil
+}
+
+// SetOutput streams the human readable output of every command to w
+func (t *Terraform) SetOutput(w io.Writer) {
+	t.tf.SetStdout(w)
+	t.tf.SetStderr(w)
+}
+
+func (t *Terraform) Init(upgrade bool) error {
+	return t.tf.Init(t.ctx, tfexec.Upgrade(upgrade))
+}
+
+// EnsureInit initializes the module unless it's been initialized before
+func (t *Terraform) EnsureInit() error {
+	if utils.Exists(pathing.SanitizeFilepath(filepath.Join(t.tf.WorkingDir(), ".terraform"))) {
+		return nil
+	}
+	return t.Init(false)
+}
+
+// Reconfigure initializes the module against a changed backend without migrating any existing state
+func (t *Terraform) Reconfigure() error {
+	return t.tf.Init(t.ctx, tfexec.Reconfigure(true))
+}
+
+// Plan computes a plan without taking a state lock, so it's safe to run alongside other operations
+func (t *Terraform) Plan() (*tfjson.Plan, error) {
+	planFile, err := ioutil.TempFile("", "plural-*.tfplan")
+	if err != nil {
+		return nil, err
+	}
+	planFile.Close()
+	defer os.Remove(planFile.Name())
+
+	if _, err := t.tf.Plan(t.ctx, tfexec.Lock(false), tfexec.Out(planFile.Name())); err != nil {
+		return nil, err
+	}
+	return t.tf.ShowPlanFile(t.ctx, planFile.Name())
+}
+
+// Apply plans the module and applies the result, returning the changes that were made
+func (t *Terraform) Apply() ([]*ResourceChange, error) {
+	planFile, err := ioutil.TempFile("", "plural-*.tfplan")
+	if err != nil {
+		return nil, err
+	}
+	planFile.Close()
+	defer os.Remove(planFile.Name())
+
+	if _, err := t.tf.Plan(t.ctx, tfexec.Out(planFile.Name())); err != nil {
+		return nil, err
+	}
+
+	plan, err := t.tf.ShowPlanFile(t.ctx, planFile.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.tf.Apply(t.ctx, tfexec.DirOrPlan(planFile.Name())); err != nil {
+		return nil, err
+	}
+	return Changes(plan), nil
+}
+
+func (t *Terraform) Destroy() error {
+	return t.tf.Destroy(t.ctx)
+}
+
+// Output returns the decoded value of every output of the module
+func (t *Terraform) Output() (map[string]interface{}, error) {
+	outputs, err := t.tf.Output(t.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]interface{})
+	for name, meta := range outputs {
+		var val interface{}
+		if err := json.Unmarshal(meta.Value, &val); err != nil {
+			return nil, fmt.Errorf("failed to parse terraform output %s: %w", name, err)
+		}
+		res[name] = val
+	}
+	return res, nil
+}
+
+// State returns the parsed state of the module
+func (t *Terraform) State() (*tfjson.State, error) {
+	return t.tf.Show(t.ctx)
+}
+
+// StatePull returns the raw state document from whatever backend the module is configured with
+func (t *Terraform) StatePull() ([]byte, error) {
+	state, err := t.tf.StatePull(t.ctx)
+	return []byte(state), err
+}
+
+// StatePush overwrites the module's state with the given raw state document
+func (t *Terraform) StatePush(state []byte) error {
+	file, err := ioutil.TempFile("", "plural-*.tfstate")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(state); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	return t.tf.StatePush(t.ctx, file.Name())
+}
+
+// Changes lists every resource a plan will act on, ignoring no-ops and reads
+func Changes(plan *tfjson.Plan) []*ResourceChange {
+	changes := make([]*ResourceChange, 0)
+	for _, change := range plan.ResourceChanges {
+		if change.Change == nil {
+			continue
+		}
+
+		actions := change.Change.Actions
+		var action string
+		switch {
+		case actions.Create():
+			action = "created"
+		case actions.Update():
+			action = "updated"
+		case actions.Delete():
+			action = "deleted"
+		case actions.Replace():
+			action = "replaced"
+		default:
+			continue
+		}
+		changes = append(changes, &ResourceChange{Address: change.Address, Action: action})
+	}
+	return changes
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	drainPollInterval   = 2 * time.Second
+	DefaultDrainTimeout = 5 * time.Minute
+)
+
+// Cordon marks a node unschedulable, so nothing new lands on it while it's being drained
+func (k *Kube) Cordon(node *v1.Node) error {
+	if node.Spec.Unschedulable {
+		return nil
+	}
+
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	_, err := k.Kube.CoreV1().Nodes().Patch(context.Background(), node.Name, "application/strategic-merge-patch+json", patch, metav1.PatchOptions{})
+	return err
+}
+
+// Drain evicts every pod a node is running except daemonset and mirror pods, which would be immediately
+// recreated on it anyway, and waits for the evicted pods to go away.  Evictions blocked by a PodDisruptionBudget
+// are retried until timeout, so a drain never takes down more of a workload than its budget allows.  Like
+// kubectl drain, pods no controller will recreate elsewhere are only evicted if force is set.
+func (k *Kube) Drain(node *v1.Node, timeout time.Duration, force bool) error {
+	pods, err := k.drainablePods(node)
+	if err != nil {
+		return err
+	}
+
+	if unmanaged := unmanagedPods(pods); len(unmanaged) > 0 && !force {
+		return fmt.Errorf("node %s is running pods without a controller, which would be lost for good: %s", node.Name, strings.Join(unmanaged, ", "))
+	}
+
+	deadline := time.Now().Add(timeout)
+	if err := k.evictAll(pods, deadline); err != nil {
+		return err
+	}
+
+	err = wait.PollImmediate(drainPollInterval, time.Until(deadline), func() (bool, error) {
+		remaining, err := k.drainablePods(node)
+		return len(remaining) == 0, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for pods on node %s to terminate", node.Name)
+	}
+	return err
+}
+
+// WaitForVolumeDetach waits until no volumes remain attached to a node, so terminating
+// it won't leave disks stuck attached to an instance that no longer exists
+func (k *Kube) WaitForVolumeDetach(node *v1.Node, timeout time.Duration) error {
+	ctx := context.Background()
+	err := wait.PollImmediate(drainPollInterval, timeout, func() (bool, error) {
+		current, err := k.Node(node.Name)
+		if err != nil {
+			return false, err
+		}
+
+		if len(current.Status.VolumesAttached) > 0 {
+			return false, nil
+		}
+
+		attachments, err := k.Kube.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, attachment := range attachments.Items {
+			if attachment.Spec.NodeName == node.Name && attachment.Status.Attached {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for volumes to detach from node %s", node.Name)
+	}
+	return err
+}
+
+// evictAll evicts pods until none are left, the api answers with a 429 for any eviction that would violate
+// a PodDisruptionBudget, so those pods are retried on the next pass until deadline
+func (k *Kube) evictAll(pods []v1.Pod, deadline time.Time) error {
+	ctx := context.Background()
+	for len(pods) > 0 {
+		blocked := make([]v1.Pod, 0)
+		for _, pod := range pods {
+			err := k.evict(ctx, pod)
+			switch {
+			case err == nil:
+			case apierrors.IsTooManyRequests(err):
+				blocked = append(blocked, pod)
+			default:
+				return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		if len(blocked) > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("timed out evicting pod %s/%s, its PodDisruptionBudget doesn't allow any more disruptions", blocked[0].Namespace, blocked[0].Name)
+		}
+
+		if len(blocked) > 0 {
+			time.Sleep(drainPollInterval)
+		}
+		pods = blocked
+	}
+	return nil
+}
+
+// evict evicts a pod with policy/v1, falling back to policy/v1beta1 on clusters older than 1.22 which don't serve
+// it.  Either answers with a 404 if the pod's already gone, so that only counts as evicted once it's confirmed.
+func (k *Kube) evict(ctx context.Context, pod v1.Pod) error {
+	meta := metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}
+	err := k.Kube.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{ObjectMeta: meta})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if gone, err := k.podGone(ctx, pod); gone || err != nil {
+		return err
+	}
+
+	err = k.Kube.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, &policyv1beta1.Eviction{ObjectMeta: meta})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if gone, gerr := k.podGone(ctx, pod); gone || gerr != nil {
+		return gerr
+	}
+	return err
+}
+
+// podGone is true if the pod no longer exists, or has been replaced by a new one with the same name
+func (k *Kube) podGone(ctx context.Context, pod v1.Pod) (bool, error) {
+	current, err := k.Kube.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return current.UID != pod.UID, nil
+}
+
+func unmanagedPods(pods []v1.Pod) []string {
+	unmanaged := make([]string, 0)
+	for i := range pods {
+		if metav1.GetControllerOf(&pods[i]) == nil {
+			unmanaged = append(unmanaged, fmt.Sprintf("%s/%s", pods[i].Namespace, pods[i].Name))
+		}
+	}
+	return unmanaged
+}
+
+func (k *Kube) drainablePods(node *v1.Node) ([]v1.Pod, error) {
+	selector := fields.OneTermEqualSelector("spec.nodeName", node.Name).String()
+	pods, err := k.Kube.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]v1.Pod, 0)
+	for _, pod := range pods.Items {
+		if isMirrorPod(pod) || isDaemonSetPod(pod) || isFinished(pod) {
+			continue
+		}
+		result = append(result, pod)
+	}
+	return result, nil
+}
+
+func isMirrorPod(pod v1.Pod) bool {
+	_, ok := pod.Annotations[v1.MirrorPodAnnotationKey]
+	return ok
+}
+
+func isDaemonSetPod(pod v1.Pod) bool {
+	if owner := metav1.GetControllerOf(&pod); owner != nil {
+		return owner.Kind == "DaemonSet"
+	}
+	return false
+}
+
+func isFinished(pod v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"bytes"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -22,10 +23,15 @@ func git(root string, args ...string) (string, error) {
 	return strings.TrimSpace(string(res)), err
 }
 
+// execute returns the stdout of cmd, keeping warnings git prints to stderr out of output that's parsed, and only
+// adding them to it when the command fails
 func execute(cmd *exec.Cmd) (string, error) {
-	res, err := cmd.CombinedOutput()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	res, err := cmd.Output()
 	if err != nil {
-		return string(res), fmt.Errorf("Command %s failed with output:\n\n%s", cmd.String(), res)
+		out := string(res) + stderr.String()
+		return out, fmt.Errorf("Command %s failed with output:\n\n%s", cmd.String(), out)
 	}
 
 	return string(res), nil
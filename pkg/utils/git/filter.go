@@ -0,0 +1,300 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// max payload of a single pkt-line, the 4 byte length header brings it to git's 65520 limit
+	maxPktData = 65516
+	// how much of a filter's output is kept in memory before spilling to disk
+	spoolMemory = 1 << 20
+)
+
+// FilterFunc transforms a single file's content for a clean or smudge request
+type FilterFunc func(pathname string, in io.Reader, out io.Writer) error
+
+// ServeFilter implements git's long-running filter process protocol (see gitattributes(5)), answering
+// every request git sends over in/out until it closes the pipe.  filters is keyed by capability, eg clean or smudge.
+func ServeFilter(in io.Reader, out io.Writer, filters map[string]FilterFunc) error {
+	reader := bufio.NewReader(in)
+	writer := bufio.NewWriter(out)
+
+	if err := filterHandshake(reader, writer, filters); err != nil {
+		return err
+	}
+
+	for {
+		headers, err := readPktList(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		command, pathname := pktValue(headers, "command"), pktValue(headers, "pathname")
+		if err := serveRequest(reader, writer, filters[command], command, pathname); err != nil {
+			return err
+		}
+	}
+}
+
+// serveRequest streams a request's content through its filter as git sends it.  git doesn't read the response until
+// it's sent all the content though, so the output is spooled until then rather than risking both pipes filling up.
+func serveRequest(reader *bufio.Reader, writer *bufio.Writer, filter FilterFunc, command, pathname string) error {
+	content := &pktContentReader{reader: reader}
+	if filter == nil {
+		if _, err := io.Copy(ioutil.Discard, content); err != nil {
+			return err
+		}
+		return writePktList(writer, "status=error")
+	}
+
+	out := &spool{}
+	defer out.Close()
+
+	ferr := filter(pathname, content, out)
+	// whatever the filter didn't read still has to be consumed before git will listen
+	if _, err := io.Copy(ioutil.Discard, content); err != nil {
+		return err
+	}
+
+	if ferr != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s %s: %s\n", command, pathname, ferr)
+		return writePktList(writer, "status=error")
+	}
+
+	if err := writePktList(writer, "status=success"); err != nil {
+		return err
+	}
+	if _, err := out.WriteTo(&pktContentWriter{writer}); err != nil {
+		return err
+	}
+	if err := writePktFlush(writer); err != nil {
+		return err
+	}
+	// an empty list keeps the success status
+	return writePktList(writer)
+}
+
+func filterHandshake(reader *bufio.Reader, writer *bufio.Writer, filters map[string]FilterFunc) error {
+	welcome, err := readPktList(reader)
+	if err != nil {
+		return err
+	}
+
+	if len(welcome) == 0 || welcome[0] != "git-filter-client" || !containsPkt(welcome, "version=2") {
+		return fmt.Errorf("unsupported filter protocol handshake: %v", welcome)
+	}
+
+	if err := writePktList(writer, "git-filter-server", "version=2"); err != nil {
+		return err
+	}
+
+	offered, err := readPktList(reader)
+	if err != nil {
+		return err
+	}
+
+	capabilities := make([]string, 0)
+	for _, capability := range offered {
+		if _, ok := filters[strings.TrimPrefix(capability, "capability=")]; ok {
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	return writePktList(writer, capabilities...)
+}
+
+// readPkt returns the payload of the next pkt-line, or nil for a flush packet
+func readPkt(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.ParseUint(string(header), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("malformed pkt-line header %q", header)
+	}
+
+	if length == 0 {
+		return nil, nil
+	}
+
+	if length < 4 {
+		return nil, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+
+	payload := make([]byte, length-4)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func readPktList(reader *bufio.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	for {
+		pkt, err := readPkt(reader)
+		if err != nil {
+			return lines, err
+		}
+
+		if pkt == nil {
+			return lines, nil
+		}
+
+		lines = append(lines, strings.TrimSuffix(string(pkt), "\n"))
+	}
+}
+
+// pktContentReader reads the content of a request as git sends it, up to the flush packet ending it
+type pktContentReader struct {
+	reader  *bufio.Reader
+	pending []byte
+	done    bool
+}
+
+func (r *pktContentReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		pkt, err := readPkt(r.reader)
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if pkt == nil {
+			r.done = true
+		}
+		r.pending = pkt
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func writePkt(writer *bufio.Writer, payload []byte) error {
+	if _, err := fmt.Fprintf(writer, "%04x", len(payload)+4); err != nil {
+		return err
+	}
+
+	_, err := writer.Write(payload)
+	return err
+}
+
+func writePktFlush(writer *bufio.Writer) error {
+	if _, err := writer.WriteString("0000"); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func writePktList(writer *bufio.Writer, lines ...string) error {
+	for _, line := range lines {
+		if err := writePkt(writer, []byte(line+"\n")); err != nil {
+			return err
+		}
+	}
+	return writePktFlush(writer)
+}
+
+func pktValue(lines []string, key string) string {
+	for _, line := range lines {
+		if strings.HasPrefix(line, key+"=") {
+			return strings.TrimPrefix(line, key+"=")
+		}
+	}
+	return ""
+}
+
+func containsPkt(lines []string, val string) bool {
+	for _, line := range lines {
+		if line == val {
+			return true
+		}
+	}
+	return false
+}
+
+// pktContentWriter frames arbitrary content into pkt-lines no larger than git allows
+type pktContentWriter struct {
+	writer *bufio.Writer
+}
+
+func (w *pktContentWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		size := len(p)
+		if size > maxPktData {
+			size = maxPktData
+		}
+
+		if err := writePkt(w.writer, p[:size]); err != nil {
+			return written, err
+		}
+		written += size
+		p = p[size:]
+	}
+	return written, nil
+}
+
+// spool holds a filter's output in memory, spilling it to a temp file once it grows past spoolMemory
+type spool struct {
+	mem  bytes.Buffer
+	file *os.File
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.mem.Len()+len(p) <= spoolMemory {
+		return s.mem.Write(p)
+	}
+
+	if s.file == nil {
+		file, err := ioutil.TempFile("", "plural-filter-")
+		if err != nil {
+			return 0, err
+		}
+		s.file = file
+
+		if _, err := s.mem.WriteTo(file); err != nil {
+			return 0, err
+		}
+	}
+	return s.file.Write(p)
+}
+
+func (s *spool) WriteTo(out io.Writer) (int64, error) {
+	if s.file == nil {
+		return s.mem.WriteTo(out)
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(out, s.file)
+}
+
+func (s *spool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func gitRequest(t *testing.T, w *bufio.Writer, command, pathname string, content []byte) {
+	if err := writePktList(w, "command="+command, "pathname="+pathname); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&pktContentWriter{w}).Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePktFlush(w); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readList(t *testing.T, r *bufio.Reader) []string {
+	lines, err := readPktList(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// readContent reads a response's content, checking no pkt-line is larger than git accepts
+func readContent(t *testing.T, r *bufio.Reader) []byte {
+	var content bytes.Buffer
+	for {
+		pkt, err := readPkt(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkt == nil {
+			return content.Bytes()
+		}
+		if len(pkt) > maxPktData {
+			t.Fatalf("pkt-line of %d bytes", len(pkt))
+		}
+		content.Write(pkt)
+	}
+}
+
+func TestServeFilter(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789abcdef"), (spoolMemory+3*maxPktData)/16)
+	filters := map[string]FilterFunc{
+		"clean": func(pathname string, in io.Reader, out io.Writer) error {
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			_, err = out.Write(bytes.ToUpper(data))
+			return err
+		},
+		// reads a little of its input, so the rest has to be drained before the next request
+		"smudge": func(pathname string, in io.Reader, out io.Writer) error {
+			if _, err := in.Read(make([]byte, 10)); err != nil {
+				return err
+			}
+			if pathname == "broken" {
+				return errors.New("broken")
+			}
+			_, err := out.Write([]byte(pathname))
+			return err
+		},
+	}
+
+	var input bytes.Buffer
+	w := bufio.NewWriter(&input)
+	writePktList(w, "git-filter-client", "version=2")
+	writePktList(w, "capability=clean", "capability=smudge", "capability=delay")
+	gitRequest(t, w, "clean", "small", []byte("hello"))
+	gitRequest(t, w, "clean", "large", large)
+	gitRequest(t, w, "clean", "empty", nil)
+	gitRequest(t, w, "smudge", "broken", large)
+	gitRequest(t, w, "smudge", "partial", large)
+	gitRequest(t, w, "process", "unknown", []byte("content"))
+
+	var output bytes.Buffer
+	if err := ServeFilter(&input, &output, filters); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&output)
+	if welcome := readList(t, r); strings.Join(welcome, ",") != "git-filter-server,version=2" {
+		t.Fatalf("unexpected welcome %v", welcome)
+	}
+	if caps := readList(t, r); strings.Join(caps, ",") != "capability=clean,capability=smudge" {
+		t.Fatalf("unexpected capabilities %v", caps)
+	}
+
+	succeeded := []struct {
+		name    string
+		content []byte
+	}{
+		{"small", []byte("HELLO")},
+		{"large", bytes.ToUpper(large)},
+		{"empty", []byte{}},
+	}
+	for _, expected := range succeeded {
+		if status := readList(t, r); strings.Join(status, ",") != "status=success" {
+			t.Fatalf("%s: unexpected status %v", expected.name, status)
+		}
+		if content := readContent(t, r); !bytes.Equal(content, expected.content) {
+			t.Fatalf("%s: unexpected content of %d bytes", expected.name, len(content))
+		}
+		if trailer := readList(t, r); len(trailer) != 0 {
+			t.Fatalf("%s: unexpected trailing status %v", expected.name, trailer)
+		}
+	}
+
+	if status := readList(t, r); strings.Join(status, ",") != "status=error" {
+		t.Fatalf("failed filter: unexpected status %v", status)
+	}
+
+	if status := readList(t, r); strings.Join(status, ",") != "status=success" {
+		t.Fatalf("partial read: unexpected status %v", status)
+	}
+	if content := readContent(t, r); string(content) != "partial" {
+		t.Fatalf("partial read: unexpected content %q", content)
+	}
+	readList(t, r)
+
+	if status := readList(t, r); strings.Join(status, ",") != "status=error" {
+		t.Fatalf("unknown command: unexpected status %v", status)
+	}
+
+	if rest, _ := ioutil.ReadAll(r); len(rest) != 0 {
+		t.Fatalf("unexpected trailing output %q", rest)
+	}
+}
+
+func TestServeFilterHandshake(t *testing.T) {
+	var input bytes.Buffer
+	w := bufio.NewWriter(&input)
+	writePktList(w, "git-filter-client", "version=1")
+
+	if err := ServeFilter(&input, ioutil.Discard, nil); err == nil {
+		t.Fatal("expected an unsupported protocol version to be rejected")
+	}
+}
+
+func TestReadPktMalformed(t *testing.T) {
+	for _, input := range []string{"zzzz", "0002", "0010abc"} {
+		if _, err := readPkt(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const attrBatchSize = 200
+
+// Files lists every tracked or untracked (but not ignored) file in the repo
+func Files(root string) ([]string, error) {
+	res, err := git(root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNul(res), nil
+}
+
+// Tracked lists every file currently in the git index
+func Tracked(root string) ([]string, error) {
+	res, err := git(root, "ls-files", "-z", "--cached")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNul(res), nil
+}
+
+// Staged lists the files added, copied or modified in the git index
+func Staged(root string) ([]string, error) {
+	res, err := git(root, "diff", "--cached", "-z", "--name-only", "--diff-filter=ACM")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNul(res), nil
+}
+
+// Attribute resolves the value of a gitattribute for each of the given files
+func Attribute(root, attr string, files []string) (map[string]string, error) {
+	result := map[string]string{}
+	for start := 0; start < len(files); start += attrBatchSize {
+		end := start + attrBatchSize
+		if end > len(files) {
+			end = len(files)
+		}
+
+		args := append([]string{"check-attr", "-z", attr, "--"}, files[start:end]...)
+		res, err := git(root, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		// output is a sequence of <path> NUL <attribute> NUL <value> NUL
+		parts := strings.Split(res, "\x00")
+		for i := 0; i+2 < len(parts); i += 3 {
+			result[parts[i]] = parts[i+2]
+		}
+	}
+
+	return result, nil
+}
+
+// IndexBlob reads the raw, untrimmed contents of a file as currently staged in the git index
+func IndexBlob(root, path string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", ":"+path)
+	cmd.Dir = root
+	res, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s from the git index: %w", path, err)
+	}
+
+	return res, nil
+}
+
+// HookPath returns the path git will use for the named hook, respecting core.hooksPath
+func HookPath(root, name string) (string, error) {
+	return git(root, "rev-parse", "--path-format=absolute", "--git-path", "hooks/"+name)
+}
+
+func splitNul(res string) []string {
+	result := make([]string, 0)
+	for _, file := range strings.Split(res, "\x00") {
+		if file != "" {
+			result = append(result, file)
+		}
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"github.com/pluralsh/plural/pkg/application"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -124,6 +126,21 @@ func (k *Kube) Secret(namespace string, name string) (*v1.Secret, error) {
 	return k.Kube.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
 }
 
+func (k *Kube) SecretUpsert(namespace string, name string, data map[string][]byte) error {
+	ctx := context.Background()
+	client := k.Kube.CoreV1().Secrets(namespace)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       data,
+	}
+
+	_, err := client.Update(ctx, secret, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, secret, metav1.CreateOptions{})
+	}
+	return err
+}
+
 func (k *Kube) Node(name string) (*v1.Node, error) {
 	return k.Kube.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
 }
@@ -144,3 +161,34 @@ func (k *Kube) FinalizeNamespace(namespace string) error {
 	_, err = client.Finalize(ctx, ns, metav1.UpdateOptions{})
 	return err
 }
+
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Usage    v1.ResourceList   `json:"usage"`
+	} `json:"items"`
+}
+
+// NodeMetrics returns the current resource usage of every node as reported by the metrics api, which
+// is an optional addon, so callers should treat an error as usage being unavailable
+func (k *Kube) NodeMetrics() (map[string]v1.ResourceList, error) {
+	raw, err := k.Kube.Discovery().RESTClient().Get().AbsPath("/apis/metrics.k8s.io/v1beta1/nodes").DoRaw(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics nodeMetricsList
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]v1.ResourceList, len(metrics.Items))
+	for _, item := range metrics.Items {
+		result[item.Metadata.Name] = item.Usage
+	}
+	return result, nil
+}
+
+func (k *Kube) Pods(namespace string) (*v1.PodList, error) {
+	return k.Kube.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+}
@@ -1,56 +1,39 @@
 package wkspace
 
 import (
-	"regexp"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/helm"
+	"github.com/pluralsh/plural/pkg/terraform"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 )
 
-type checker func(s string) bool
-
-var alwaysErr checker = func(s string) bool { return false }
-
-
-func (c checker) execSuppressed(command string, args ...string) (err error) {
-	for retry := 2; retry >= 0; retry-- {
-		utils.Highlight("%s %s ~> ", command, strings.Join(args, " "))
-		cmd, out := executor.SuppressedCommand(command, args...)
-		err = executor.RunCommand(cmd, out)
-		if err == nil || c(out.Format()) {
-			break
-		}
-		fmt.Printf("retrying command, number of retries remaining: %d\n", retry)
-	}
-
-	return
-}
-
 func (w *Workspace) DestroyHelm() error {
 	// ensure current kubeconfig is correct before destroying stuff
 	w.Provider.KubeConfig()
 	name := w.Installation.Repository.Name
 
 	ns := w.Config.Namespace(name)
-	if err := alwaysErr.execSuppressed("helm", "get", "values", name, "-n", ns); err != nil {
-		fmt.Println("Helm already uninstalled, continuing...")
-		return nil
+	utils.Highlight("Uninstalling helm release %s in namespace %s\n", name, ns)
+	if err := helm.Uninstall(ns, name); err != nil {
+		if errors.Is(err, helm.ErrReleaseNotFound) {
+			fmt.Println("Helm already uninstalled, continuing...")
+			return nil
+		}
+		return err
 	}
-
-	r, _ := regexp.Compile("release.*not found")
-	var ignoreNotFound checker = func(s string) bool { return r.MatchString(s) }
-	return ignoreNotFound.execSuppressed("helm", "del", name, "-n", ns)
+	return nil
 }
 
 func (w *Workspace) Bounce() error {
-	return w.ToMinimal().BounceHelm()
+	return w.ToMinimal().BounceHelm(false)
 }
 
 func (w *Workspace) HelmDiff() error {
@@ -99,10 +82,29 @@ func (w *Workspace) DestroyTerraform() error {
 		kube.FinalizeNamespace(ns)
 	})
 
-	os.Chdir(path)
-	if err := alwaysErr.execSuppressed("terraform", "init", "-upgrade"); err != nil {
+	tf, err := terraform.New(path)
+	if err != nil {
 		return err
 	}
 
-	return alwaysErr.execSuppressed("terraform", "destroy", "-auto-approve")
+	if err := runTerraform(tf, "init -upgrade", func() error { return tf.Init(true) }); err != nil {
+		return err
+	}
+
+	return runTerraform(tf, "destroy -auto-approve", tf.Destroy)
+}
+
+func runTerraform(tf *terraform.Terraform, desc string, fn func() error) (err error) {
+	for retry := 2; retry >= 0; retry-- {
+		utils.Highlight("terraform %s ~> ", desc)
+		out := executor.SuppressedOutput()
+		tf.SetOutput(out)
+		err = executor.RunFunc(fn, out)
+		if err == nil {
+			break
+		}
+		fmt.Printf("retrying command, number of retries remaining: %d\n", retry)
+	}
+
+	return
 }
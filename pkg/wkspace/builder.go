@@ -42,22 +42,30 @@ func New(client *api.Client, inst *api.Installation) (*Workspace, error) {
 		return nil, err
 	}
 
-	prov, err := provider.FromManifest(project)
+	manifestPath := manifestPath(inst.Repository.Name)
+	man, err := manifest.Read(manifestPath)
+	var links *manifest.Links
+	target := manifest.PrimaryTarget
+	if err == nil {
+		links = man.Links
+		if man.Target != "" {
+			target = man.Target
+		}
+	}
+
+	prov, err := provider.FromTarget(project, target)
 	if err != nil {
 		return nil, err
 	}
 
-	conf := config.Read()
-	ctx, err := manifest.ReadContext(manifest.ContextPath())
+	conf, err := project.TargetConfig(target)
 	if err != nil {
 		return nil, err
 	}
 
-	manifestPath := manifestPath(inst.Repository.Name)
-	man, err := manifest.Read(manifestPath)
-	var links *manifest.Links
-	if err == nil {
-		links = man.Links
+	ctx, err := manifest.ReadContext(manifest.ContextPath())
+	if err != nil {
+		return nil, err
 	}
 
 	wk := &Workspace{
@@ -187,7 +195,8 @@ func (wk *Workspace) buildExecution(repoRoot string) error {
 
 	exec, _ := executor.GetExecution(pathing.SanitizeFilepath(filepath.Join(wkspaceRoot)), "deploy")
 
-	return executor.DefaultExecution(name, exec).Flush(repoRoot)
+	policies := wk.Manifest != nil && wk.Manifest.EnforcePolicies
+	return executor.DefaultExecution(name, exec, policies).Flush(repoRoot)
 }
 
 func (wk *Workspace) buildDiff(repoRoot string) error {
@@ -32,6 +32,7 @@ func (wk *Workspace) BuildManifest(prev *manifest.Manifest) *manifest.Manifest {
 		Dependencies: buildDependencies(repository.Name, wk.Charts, wk.Terraform),
 		Context: wk.Provider.Context(),
 		Links: prev.Links,
+		Target: prev.Target,
 	}
 }
 
@@ -0,0 +1,105 @@
+package wkspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/terraform"
+)
+
+// StateMigration records which resources in a repo's terraform state could be carried over to a new provider
+type StateMigration struct {
+	Repo     string
+	Migrated []string
+	Dropped  []string
+}
+
+// PullState fetches the current terraform state of the module in dir from whatever backend it's configured with
+func PullState(dir string) ([]byte, error) {
+	tf, err := terraform.New(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tf.EnsureInit(); err != nil {
+		return nil, err
+	}
+
+	state, err := tf.StatePull()
+	if err != nil {
+		return nil, fmt.Errorf("terraform state pull failed in %s: %w", dir, err)
+	}
+	return state, nil
+}
+
+// MigrateState pushes the resources in state not managed by any of the dropped terraform providers into the
+// backend dir is now configured with.  Resources belonging to the old cloud can't be moved, so are only reported.
+func MigrateState(repo, dir string, state []byte, dropped []string) (*StateMigration, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(state, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform state for %s: %w", repo, err)
+	}
+
+	migration := &StateMigration{Repo: repo, Migrated: make([]string, 0), Dropped: make([]string, 0)}
+	resources, _ := doc["resources"].([]interface{})
+	kept := make([]interface{}, 0)
+	for _, res := range resources {
+		resource, ok := res.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		address := stateAddress(resource)
+		if providerName, _ := resource["provider"].(string); droppedProvider(providerName, dropped) {
+			migration.Dropped = append(migration.Dropped, address)
+			continue
+		}
+
+		kept = append(kept, resource)
+		migration.Migrated = append(migration.Migrated, address)
+	}
+
+	tf, err := terraform.New(dir)
+	if err != nil {
+		return migration, err
+	}
+
+	if err := tf.Reconfigure(); err != nil {
+		return migration, err
+	}
+
+	if len(kept) == 0 {
+		return migration, nil
+	}
+
+	doc["resources"] = kept
+	filtered, err := json.Marshal(doc)
+	if err != nil {
+		return migration, err
+	}
+
+	return migration, tf.StatePush(filtered)
+}
+
+// providers in state are recorded like provider["registry.terraform.io/hashicorp/aws"]
+func droppedProvider(providerName string, dropped []string) bool {
+	for _, name := range dropped {
+		if strings.Contains(providerName, "/"+name+"\"") {
+			return true
+		}
+	}
+	return false
+}
+
+func stateAddress(resource map[string]interface{}) string {
+	address := fmt.Sprintf("%s.%s", resource["type"], resource["name"])
+	if mode, _ := resource["mode"].(string); mode == "data" {
+		address = "data." + address
+	}
+
+	if module, ok := resource["module"].(string); ok && module != "" {
+		address = module + "." + address
+	}
+	return address
+}
@@ -1,22 +1,24 @@
 package wkspace
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"text/template"
 
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/pluralsh/plural/pkg/helm"
 	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/output"
 	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/terraform"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"helm.sh/helm/v3/pkg/chartutil"
 )
 
 type MinimalWorkspace struct {
@@ -32,13 +34,22 @@ func Minimal(name string) (*MinimalWorkspace, error) {
 		return nil, err
 	}
 
-	prov, err := provider.GetProvider()
+	project, err := manifest.ReadProject(pathing.SanitizeFilepath(filepath.Join(root, "workspace.yaml")))
+	if err != nil {
+		return nil, err
+	}
+
+	target := manifest.RepoTarget(name)
+	prov, err := provider.FromTarget(project, target)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := project.TargetConfig(target)
 	if err != nil {
 		return nil, err
 	}
 
-	project, _ := manifest.ReadProject(pathing.SanitizeFilepath(filepath.Join(root, "workspace.yaml")))
-	conf := config.Read()
 	return &MinimalWorkspace{Name: name, Provider: prov, Config: &conf, Manifest: project}, nil
 }
 
@@ -51,6 +62,32 @@ func FormatValues(w io.Writer, vals string, output *output.Output) (err error) {
 	return
 }
 
+// RenderValues returns the values of app's chart with any terraform outputs they import filled in
+func RenderValues(root, app string) (map[string]interface{}, error) {
+	valsFile := pathing.SanitizeFilepath(filepath.Join(root, app, "helm", app, "values.yaml"))
+	vals, err := utils.ReadFile(valsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// outputs won't exist until the repo's terraform has been applied
+	out, err := output.Read(pathing.SanitizeFilepath(filepath.Join(root, app, "output.yaml")))
+	if err != nil {
+		out = output.New()
+	}
+
+	var buf bytes.Buffer
+	if err := FormatValues(&buf, vals, out); err != nil {
+		return nil, err
+	}
+
+	res, err := chartutil.ReadValues(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid yaml in %s: %w", valsFile, err)
+	}
+	return res, nil
+}
+
 func templateVals(app, path string) (backup string, err error) {
 	root, _ := utils.ProjectRoot()
 	valsFile := pathing.SanitizeFilepath(filepath.Join(path, "values.yaml"))
@@ -80,7 +117,8 @@ func templateVals(app, path string) (backup string, err error) {
 	return
 }
 
-func (m *MinimalWorkspace) BounceHelm(extraArgs ...string) error {
+// BounceHelm installs or upgrades this repo's helm release, skipping the named subcharts
+func (m *MinimalWorkspace) BounceHelm(wait bool, skip ...string) error {
 	path, err := filepath.Abs(pathing.SanitizeFilepath(filepath.Join("helm", m.Name)))
 	if err != nil {
 		return err
@@ -91,13 +129,23 @@ func (m *MinimalWorkspace) BounceHelm(extraArgs ...string) error {
 	}
 
 	namespace := m.Config.Namespace(m.Name)
-	utils.Warn("helm upgrade --install --namespace %s %s %s %s\n", namespace, m.Name, path, strings.Join(extraArgs, " "))
-	args := []string{}
-	defaultArgs := []string{"upgrade", "--install", "--skip-crds", "--namespace", namespace, m.Name, path}
-	args = append(args, defaultArgs...)
-	args = append(args, extraArgs...)
-	return utils.Cmd(m.Config,
-		"helm", args...)
+	opts := &helm.UpgradeOptions{SkipCRDs: true, Wait: wait}
+	for _, chart := range skip {
+		opts.Set = append(opts.Set, fmt.Sprintf("%s.enabled=false", chart))
+	}
+
+	utils.Highlight("Upgrading helm release %s in namespace %s\n", m.Name, namespace)
+	changes, err := helm.Upgrade(namespace, m.Name, path, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Action != "unchanged" {
+			fmt.Printf("%s %s\n", change.Resource, change.Action)
+		}
+	}
+	return nil
 }
 
 func (m *MinimalWorkspace) DiffHelm() error {
@@ -110,30 +158,45 @@ func (m *MinimalWorkspace) DiffHelm() error {
 		defer os.Rename(backup, pathing.SanitizeFilepath(filepath.Join(path, "values.yaml")))
 	}
 
+	diffFolder, err := m.constructDiffFolder()
+	if err != nil {
+		return err
+	}
+
+	outfile, err := os.Create(pathing.SanitizeFilepath(filepath.Join(diffFolder, "helm")))
+	if err != nil {
+		return err
+	}
+	defer outfile.Close()
+
 	namespace := m.Config.Namespace(m.Name)
-	utils.Warn("helm diff upgrade --install --show-secrets --reset-values --namespace %s %s %s\n", namespace, m.Name, path)
-	if err := m.runDiff("helm", "diff", "upgrade", "--show-secrets", "--reset-values", "--install", "--namespace", namespace, m.Name, path); err != nil {
-		utils.Note("helm diff failed, this command can be flaky, but let us know regardless")
+	utils.Highlight("Diffing helm release %s in namespace %s\n", m.Name, namespace)
+	if err := helm.Diff(namespace, m.Name, path, nil, &diff.TeeWriter{File: outfile}); err != nil {
+		utils.Note("helm diff failed with %s\n", err)
 	}
 	return nil
 }
 
 func (m *MinimalWorkspace) DiffTerraform() error {
-	return m.runDiff("terraform", "plan")
-}
-
-func (m *MinimalWorkspace) runDiff(command string, args ...string) error {
 	diffFolder, err := m.constructDiffFolder()
-	outfile, err := os.Create(pathing.SanitizeFilepath(pathing.SanitizeFilepath(filepath.Join(diffFolder, command))))
+	if err != nil {
+		return err
+	}
+
+	outfile, err := os.Create(pathing.SanitizeFilepath(filepath.Join(diffFolder, "terraform")))
 	if err != nil {
 		return err
 	}
 	defer outfile.Close()
 
-	cmd := exec.Command(command, args...)
-	cmd.Stdout = &diff.TeeWriter{File: outfile}
-	cmd.Stderr = os.Stdout
-	return cmd.Run()
+	tf, err := terraform.New(".")
+	if err != nil {
+		return err
+	}
+
+	tf.SetOutput(&diff.TeeWriter{File: outfile})
+	_, err = tf.Plan()
+	return err
 }
 
 func (m *MinimalWorkspace) constructDiffFolder() (string, error) {
@@ -8,7 +8,8 @@ import (
 )
 
 func Preflight() (bool, error) {
-	requirements := []string{"helm", "kubectl", "terraform", "git"}
+	// terraform isn't required, the version each workspace needs is installed on demand, and helm is built in
+	requirements := []string{"kubectl", "git"}
 	for _, req := range requirements {
 		if ok, _ := utils.Which(req); !ok {
 			return true, utils.HighlightError(fmt.Errorf("%s not installed", req))